@@ -1,64 +1,317 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
-	"syncer-playground/pkg/chat"
-	"syncer-playground/pkg/config"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
 )
 
+const (
+	appliedPositionTable = "syncer_applied_position"
+	localChangesTable    = "syncer_local_changes"
+
+	// Prefix of the replication origins changes are applied under, which servers
+	// skip as a peer's by default
+	originPrefix = "syncer_"
+
+	pushInterval  = time.Second
+	pushBatchSize = 100
+
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 30 * time.Second
+
+	// Highest protocol version the client speaks
+	protocolVersion = 1
+
+	// Channel the outcome of each pushed local change is announced on
+	outboxChannel = "syncer_outbox"
+)
+
+// localCaptureSQL installs the outbox and the trigger function recording local
+// changes in it for pushing upstream. Changes stay pending until the server reports
+// their outcome, which is then recorded with them. Changes applied from upstream
+// run with syncer.version set to the row version they carry; instead of being
+// recorded, they move the version the next local change of the row is based on.
+// Like all of the client's own DDL, it runs with syncer.capture off, out of reach
+// of a DDL capture trigger on this database.
+const localCaptureSQL = `
+SET LOCAL syncer.capture = off;
+
+CREATE TABLE IF NOT EXISTS ` + localChangesTable + ` (
+	id bigserial PRIMARY KEY,
+	table_name text NOT NULL,
+	operation text NOT NULL,
+	key_columns jsonb NOT NULL,
+	old_row jsonb,
+	new_row jsonb,
+	base_version text,
+	changed_at timestamptz NOT NULL DEFAULT clock_timestamp()
+);
+ALTER TABLE ` + localChangesTable + `
+	ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'pending',
+	ADD COLUMN IF NOT EXISTS conflict boolean,
+	ADD COLUMN IF NOT EXISTS row_version text,
+	ADD COLUMN IF NOT EXISTS message text,
+	ADD COLUMN IF NOT EXISTS acknowledged_at timestamptz;
+CREATE INDEX IF NOT EXISTS ` + localChangesTable + `_pending ON ` + localChangesTable + ` (id) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS syncer_row_versions (
+	table_name text NOT NULL,
+	row_key jsonb NOT NULL,
+	version text NOT NULL,
+	PRIMARY KEY (table_name, row_key)
+);
+
+CREATE OR REPLACE FUNCTION syncer_capture_change() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+	tbl text := TG_TABLE_SCHEMA || '.' || TG_TABLE_NAME;
+	version text := coalesce(current_setting('syncer.version', true), '');
+	old_row jsonb;
+	new_row jsonb;
+	old_key jsonb;
+	new_key jsonb;
+BEGIN
+	IF TG_OP <> 'INSERT' THEN
+		old_row := to_jsonb(OLD);
+		SELECT jsonb_object_agg(k, old_row -> k) INTO old_key FROM unnest(TG_ARGV) k;
+	END IF;
+	IF TG_OP <> 'DELETE' THEN
+		new_row := to_jsonb(NEW);
+		SELECT jsonb_object_agg(k, new_row -> k) INTO new_key FROM unnest(TG_ARGV) k;
+	END IF;
+
+	IF version <> '' THEN
+		IF old_key IS DISTINCT FROM new_key THEN
+			DELETE FROM syncer_row_versions WHERE table_name = tbl AND row_key = old_key;
+		END IF;
+		IF new_key IS NOT NULL THEN
+			INSERT INTO syncer_row_versions VALUES (tbl, new_key, version)
+			ON CONFLICT (table_name, row_key) DO UPDATE SET version = EXCLUDED.version;
+		END IF;
+		RETURN NULL;
+	END IF;
+
+	INSERT INTO ` + localChangesTable + ` (table_name, operation, key_columns, old_row, new_row, base_version)
+	VALUES (tbl, TG_OP, to_jsonb(TG_ARGV), old_row, new_row,
+		(SELECT v.version FROM syncer_row_versions v WHERE v.table_name = tbl AND v.row_key = coalesce(old_key, new_key)));
+	RETURN NULL;
+END
+$$;
+`
+
+// appliedPosition is the position of the last event applied from a server, and its
+// cursor on servers whose bus keeps events. It is written in the same local
+// transaction as the changes it covers.
+type appliedPosition struct {
+	Server    string `gorm:"primaryKey"`
+	Position  string `gorm:"not null"`
+	Cursor    string `gorm:"not null;default:''"`
+	UpdatedAt time.Time
+}
+
+func (appliedPosition) TableName() string {
+	return appliedPositionTable
+}
+
+// localChange is a change captured on the local database, waiting to be pushed
+type localChange struct {
+	ID          uint64
+	Table       string `gorm:"column:table_name"`
+	Operation   string
+	KeyColumns  string
+	OldRow      *string
+	NewRow      *string
+	BaseVersion *string
+	ChangedAt   time.Time
+}
+
+// upstream is a server the client streams changes from
+type upstream struct {
+	config.Upstream
+	conn *grpc.ClientConn
+	cli  chat.ChatServiceClient
+	// Connection to the database the changes are applied to
+	db *gorm.DB
+	// Token of the session on the server, presented when reconnecting
+	session string
+}
+
 type Client struct {
-	db          *gorm.DB
-	pgOnlyConn  *grpc.ClientConn
-	pgRedisConn *grpc.ClientConn
-	pgOnlyCli   chat.ChatServiceClient
-	pgRedisCli  chat.ChatServiceClient
+	upstreams []*upstream
+	// Upstream local changes are captured for and pushed to, if bidirectional
+	push *upstream
+	// Target databases by DSN, shared by the upstreams applying to them
+	dbs map[string]*gorm.DB
+	// Apply each upstream transaction in one local transaction
+	transactional bool
+	// Execute DDL statements captured upstream
+	applySchemaChanges bool
+	// Events servers may send ahead of what was applied, 0 without flow control,
+	// and how often applied positions are acknowledged
+	ackWindow   int
+	ackInterval time.Duration
+	// Capture local changes and push them upstream as origin
+	bidirectional bool
+	origin        string
+	// Key columns of the tables local changes are captured on
+	captured map[string]string
+	mu       sync.Mutex
+	// Signalled when the stream from the push upstream connects, to replay the outbox
+	connected chan struct{}
+	// State of the stream from each server
+	states map[string]ConnState
+
+	// OnStateChange, if set, is called on every state transition of a stream, with
+	// the error that caused it if any
+	OnStateChange func(server string, state ConnState, err error)
 }
 
 func NewClient(cfg *config.Config) (*Client, error) {
-	// Connect to local PostgreSQL
-	db, err := gorm.Open(postgres.Open(cfg.GetPostgresDSN()), &gorm.Config{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	if len(cfg.Client.Upstreams) == 0 {
+		return nil, errors.New("no upstreams configured")
+	}
+	if cfg.Client.AckWindow > 0 && cfg.Client.AckInterval <= 0 {
+		return nil, errors.New("the ack interval must be positive")
 	}
 
-	// Connect to PostgreSQL-only server
-	pgOnlyConn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to PostgreSQL-only server: %w", err)
+	c := &Client{
+		dbs:                make(map[string]*gorm.DB),
+		transactional:      cfg.Client.TransactionalApply,
+		applySchemaChanges: cfg.Client.ApplySchemaChanges,
+		ackWindow:          cfg.Client.AckWindow,
+		ackInterval:        cfg.Client.AckInterval,
+		bidirectional:      cfg.Client.Bidirectional,
+		origin:             cfg.Client.Origin,
+		captured:           make(map[string]string),
+		connected:          make(chan struct{}, 1),
+		states:             make(map[string]ConnState),
+	}
+	for _, upstreamConfig := range cfg.Client.Upstreams {
+		u, err := c.connect(upstreamConfig)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.upstreams = append(c.upstreams, u)
+		if u.Name == cfg.Client.PushUpstream {
+			c.push = u
+		}
 	}
 
-	// Connect to PostgreSQL + Redis server
-	pgRedisConn, err := grpc.Dial("localhost:50052", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if c.bidirectional {
+		if c.push == nil {
+			c.Close()
+			return nil, fmt.Errorf("push upstream %s is not configured", cfg.Client.PushUpstream)
+		}
+		if err := c.push.db.Exec(localCaptureSQL).Error; err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to set up local change capture: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// connect opens the connections of an upstream, reusing the one to its target
+// database if another upstream applies to it too
+func (c *Client) connect(cfg config.Upstream) (*upstream, error) {
+	db, ok := c.dbs[cfg.TargetDSN]
+	if !ok {
+		var err error
+		db, err = gorm.Open(postgres.Open(cfg.TargetDSN), &gorm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to target database of %s: %w", cfg.Name, err)
+		}
+		c.dbs[cfg.TargetDSN] = db
+		if err := db.AutoMigrate(&appliedPosition{}); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s: %w", appliedPositionTable, err)
+		}
+	}
+
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up TLS for %s: %w", cfg.Name, err)
+	}
+	conn, err := grpc.Dial(cfg.Address, grpc.WithTransportCredentials(creds))
 	if err != nil {
-		pgOnlyConn.Close()
-		return nil, fmt.Errorf("failed to connect to PostgreSQL + Redis server: %w", err)
+		return nil, fmt.Errorf("failed to connect to %s at %s: %w", cfg.Name, cfg.Address, err)
+	}
+	return &upstream{Upstream: cfg, conn: conn, cli: chat.NewChatServiceClient(conn), db: db}, nil
+}
+
+// transportCredentials returns the credentials securing the connection to an
+// upstream, none unless TLS is enabled
+func transportCredentials(cfg config.Upstream) (credentials.TransportCredentials, error) {
+	if !cfg.TLS.Enabled {
+		return insecure.NewCredentials(), nil
 	}
 
-	return &Client{
-		db:          db,
-		pgOnlyConn:  pgOnlyConn,
-		pgRedisConn: pgRedisConn,
-		pgOnlyCli:   chat.NewChatServiceClient(pgOnlyConn),
-		pgRedisCli:  chat.NewChatServiceClient(pgRedisConn),
-	}, nil
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.TLS.ServerName,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+	}
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.TLS.CAFile)
+		}
+	}
+	if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
 }
 
 func (c *Client) Close() error {
 	var errs []error
-	if err := c.pgOnlyConn.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close PostgreSQL-only connection: %w", err))
+	for _, u := range c.upstreams {
+		if err := u.conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close connection to %s: %w", u.Name, err))
+		}
 	}
-	if err := c.pgRedisConn.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close PostgreSQL + Redis connection: %w", err))
+	for _, db := range c.dbs {
+		if sqlDB, err := db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close database connection: %w", err))
+			}
+		}
 	}
 	if len(errs) > 0 {
 		return fmt.Errorf("errors closing connections: %v", errs)
@@ -66,87 +319,912 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// ConnState is the state of the stream from an upstream server
+type ConnState int
+
+const (
+	// StateConnecting is opening the stream
+	StateConnecting ConnState = iota
+	// StateStreaming is receiving events
+	StateStreaming
+	// StateBackoff is waiting to reconnect after the stream failed
+	StateBackoff
+	// StateStopped gave up on the server, or was cancelled
+	StateStopped
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateStreaming:
+		return "streaming"
+	case StateBackoff:
+		return "backoff"
+	case StateStopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("ConnState(%d)", int(s))
+}
+
+// State returns the state of the stream from server
+func (c *Client) State(server string) ConnState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.states[server]
+}
+
+// setState records a state transition of the stream from server, with the error
+// that caused it if any, and reports it to OnStateChange
+func (c *Client) setState(server string, state ConnState, err error) {
+	c.mu.Lock()
+	from, ok := c.states[server]
+	c.states[server] = state
+	hook := c.OnStateChange
+	c.mu.Unlock()
+	if ok && from == state {
+		return
+	}
+
+	transition := state.String()
+	if ok {
+		transition = from.String() + " -> " + transition
+	}
+	if err != nil {
+		log.Printf("Stream from %s: %s: %v", server, transition, err)
+	} else {
+		log.Printf("Stream from %s: %s", server, transition)
+	}
+	if hook != nil {
+		hook(server, state, err)
+	}
+}
+
+// StreamChanges applies the changes of every upstream until ctx is done or one of
+// them fails for good
 func (c *Client) StreamChanges(ctx context.Context) error {
 	var wg sync.WaitGroup
-	errChan := make(chan error, 2)
+	errChan := make(chan error, len(c.upstreams))
 
-	// Stream changes from PostgreSQL-only server
-	wg.Add(1)
+	// Stream changes from every upstream, each reconnecting on its own
+	for _, u := range c.upstreams {
+		wg.Add(1)
+		go func(u *upstream) {
+			defer wg.Done()
+			errChan <- c.stream(ctx, u)
+		}(u)
+	}
+
+	// Push local changes upstream
+	if c.bidirectional {
+		go c.pushLoop(ctx)
+	}
+
+	// Wait for errors or context cancellation
 	go func() {
-		defer wg.Done()
-		stream, err := c.pgOnlyCli.StreamDataChanges(ctx, &chat.StreamDataChangesRequest{})
+		wg.Wait()
+		close(errChan)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stream applies the changes of upstream u until ctx is done or it fails with an
+// error retrying cannot fix. Broken streams are reopened after a backoff, from the
+// last applied position.
+func (c *Client) stream(ctx context.Context, u *upstream) error {
+	a, err := c.newApplier(u)
+	if err != nil {
+		c.setState(u.Name, StateStopped, err)
+		return err
+	}
+	defer a.close()
+
+	failures := 0
+	for {
+		c.setState(u.Name, StateConnecting, nil)
+		received, err := c.receive(ctx, u, a)
+		// The transaction the stream broke off in is sent again from its BEGIN, and
+		// a snapshot broken off is taken again
+		a.rollback()
+		a.snapshot = false
+
+		if ctx.Err() != nil {
+			c.setState(u.Name, StateStopped, nil)
+			return ctx.Err()
+		}
+		if !retryable(err) {
+			c.setState(u.Name, StateStopped, err)
+			return fmt.Errorf("stream from %s failed: %w", u.Name, err)
+		}
+
+		if received {
+			failures = 0
+		}
+		delay := backoff(failures)
+		failures++
+		c.setState(u.Name, StateBackoff, err)
+		log.Printf("Reconnecting to %s in %s", u.Name, delay)
+		select {
+		case <-ctx.Done():
+			c.setState(u.Name, StateStopped, nil)
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// receive opens a stream from upstream u and applies its events until it breaks. It
+// reports whether any event arrived.
+func (c *Client) receive(ctx context.Context, u *upstream, a *applier) (bool, error) {
+	// Every upstream may be the same server, which takes one stream per client id
+	connect := &chat.ConnectRequest{
+		ClientId:        c.origin + "_" + u.Name,
+		Capabilities:    []string{"snapshot"},
+		ProtocolVersion: protocolVersion,
+		Cursor:          a.last,
+		SessionToken:    u.session,
+	}
+	if c.applySchemaChanges {
+		connect.Capabilities = append(connect.Capabilities, "schema-changes")
+	}
+	if c.ackWindow > 0 {
+		connect.Capabilities = append(connect.Capabilities, "flow-control")
+	}
+	if c.bidirectional && u == c.push {
+		connect.Origin = c.origin
+		connect.Capabilities = append(connect.Capabilities, "push")
+	}
+	session, err := u.cli.Connect(ctx, connect)
+	if err != nil {
+		return false, err
+	}
+	u.session = session.GetSessionToken()
+
+	req := &chat.StreamDataChangesRequest{
+		ClientId:        session.GetClientId(),
+		SessionToken:    session.GetSessionToken(),
+		Tables:          u.Tables,
+		ResumePosition:  a.last,
+		ResumeCursor:    a.cursor,
+		InitialSnapshot: true,
+	}
+	var stream interface {
+		Recv() (*chat.DataChangeEvent, error)
+	}
+	var acks *acker
+	if c.ackWindow > 0 && slices.Contains(session.GetCapabilities(), "flow-control") {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		flowStream, err := u.cli.SyncDataChanges(ctx)
 		if err != nil {
-			errChan <- fmt.Errorf("failed to start streaming from PostgreSQL-only server: %w", err)
-			return
+			return false, err
+		}
+		err = flowStream.Send(&chat.SyncDataChangesRequest{Message: &chat.SyncDataChangesRequest_Start{Start: req}})
+		if err != nil {
+			return false, err
+		}
+		acks = &acker{stream: flowStream, window: c.ackWindow, applied: a.last, kick: make(chan struct{}, 1)}
+		// Nothing is sent before the first ack
+		if err := acks.send(); err != nil {
+			return false, err
 		}
+		go acks.run(ctx, c.ackInterval)
+		stream = flowStream
+	} else {
+		stream, err = u.cli.StreamDataChanges(ctx, req)
+		if err != nil {
+			return false, err
+		}
+	}
+	c.setState(u.Name, StateStreaming, nil)
+	if u == c.push {
+		select {
+		case c.connected <- struct{}{}:
+		default:
+		}
+	}
 
-		for {
-			event, err := stream.Recv()
-			if err != nil {
-				errChan <- fmt.Errorf("error receiving from PostgreSQL-only server: %w", err)
-				return
-			}
+	received := false
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
 
-			log.Printf("Received event from PostgreSQL-only server: %v", event)
-			// Apply changes to local database
-			if err := c.applyChange(event); err != nil {
-				log.Printf("Error applying change from PostgreSQL-only server: %v", err)
+		log.Printf("Received event from %s: %v", u.Name, event)
+		// Apply changes to local database. A change that fails ends the stream, which
+		// is reopened from the last position applied so nothing after it is lost. It
+		// does not count as progress, so a change failing for good backs off.
+		if err := a.apply(event); err != nil {
+			return false, fmt.Errorf("failed to apply change from %s: %w", u.Name, err)
+		}
+		if acks != nil {
+			// Snapshot rows cannot be resumed from, but take credit one by one
+			applied := a.last
+			if a.snapshot && event.Position > applied {
+				applied = event.Position
 			}
+			acks.record(applied)
 		}
-	}()
+	}
+}
 
-	// Stream changes from PostgreSQL + Redis server
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		stream, err := c.pgRedisCli.StreamDataChanges(ctx, &chat.StreamDataChangesRequest{})
-		if err != nil {
-			errChan <- fmt.Errorf("failed to start streaming from PostgreSQL + Redis server: %w", err)
+// acker acknowledges the position an applier reached to a flow controlled stream,
+// granting the server credit for the window past it
+type acker struct {
+	stream chat.ChatService_SyncDataChangesClient
+	window int
+
+	mu      sync.Mutex
+	applied string
+	// Events received since the last ack
+	received int
+	// Signalled once half the window was received since the last ack
+	kick chan struct{}
+}
+
+// run acknowledges every interval, and as soon as half the window was received,
+// until ctx is done or the stream breaks
+func (k *acker) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-k.kick:
+		}
+		if err := k.send(); err != nil {
 			return
 		}
+	}
+}
 
-		for {
-			event, err := stream.Recv()
-			if err != nil {
-				errChan <- fmt.Errorf("error receiving from PostgreSQL + Redis server: %w", err)
-				return
-			}
+// record records an event received, applied up to position applied
+func (k *acker) record(applied string) {
+	k.mu.Lock()
+	k.applied = applied
+	k.received++
+	kick := k.received >= k.window/2
+	k.mu.Unlock()
+
+	if kick {
+		select {
+		case k.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (k *acker) send() error {
+	k.mu.Lock()
+	ack := &chat.Ack{AppliedPosition: k.applied, Window: uint32(k.window)}
+	k.received = 0
+	k.mu.Unlock()
+	return k.stream.Send(&chat.SyncDataChangesRequest{Message: &chat.SyncDataChangesRequest_Ack{Ack: ack}})
+}
+
+// retryable reports whether a stream failing with err is worth reopening. Servers
+// going away and expired sessions are; requests the server rejects, or a resume
+// position it no longer holds, are not.
+func retryable(err error) bool {
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Unauthenticated, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before reconnecting after failures consecutive failed
+// attempts: doubling from minBackoff up to maxBackoff, less up to half of it at
+// random so clients do not reconnect in lockstep
+func backoff(failures int) time.Duration {
+	d := maxBackoff
+	if failures < 16 {
+		d = min(minBackoff<<failures, maxBackoff)
+	}
+	return d - time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// position returns the position and cursor of the last event applied from server
+func position(db *gorm.DB, server string) (string, string, error) {
+	var applied appliedPosition
+	err := db.Where("server = ?", server).Take(&applied).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load applied position for %s: %w", server, err)
+	}
+	return applied.Position, applied.Cursor, nil
+}
+
+// savePosition records event as the last applied from server
+func savePosition(db *gorm.DB, server string, event *chat.DataChangeEvent) error {
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "server"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "cursor", "updated_at"}),
+	}).Create(&appliedPosition{Server: server, Position: event.Position, Cursor: event.Cursor}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save applied position: %w", err)
+	}
+	return nil
+}
+
+// applier applies the events of one stream, grouping the row changes of each upstream
+// transaction into a local transaction when transactional apply is enabled. The
+// applied position is saved along with the changes, so every event is applied once.
+// Changes are applied under a replication origin of their own, so a server
+// replicating the local database can tell them from local writes.
+type applier struct {
+	client   *Client
+	upstream *upstream
+	db       *gorm.DB
+	// Position of the last event applied, events at or before it are skipped
+	last string
+	// Cursor of the last event applied, if the server's bus keeps events
+	cursor string
+	tx     *gorm.DB
+	// Set between the BEGIN and COMMIT of a snapshot, whose rows are applied
+	// without saving their position
+	snapshot bool
+}
+
+// newApplier creates the replication origin changes from upstream u are applied
+// under and opens the connection applying them
+func (c *Client) newApplier(u *upstream) (*applier, error) {
+	last, cursor, err := position(u.db, u.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := originPrefix + c.origin + "_" + u.Name
+	err = u.db.Exec("SELECT pg_replication_origin_create(?) WHERE NOT EXISTS (SELECT 1 FROM pg_replication_origin WHERE roname = ?)",
+		origin, origin).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replication origin %s: %w", origin, err)
+	}
+	db, err := openOriginDB(u.TargetDSN, origin)
+	if err != nil {
+		return nil, err
+	}
+	return &applier{client: c, upstream: u, db: db, last: last, cursor: cursor}, nil
+}
+
+// openOriginDB opens a pool whose connection marks every transaction it commits as
+// replicated from origin. An origin can only be active in one session at a time,
+// so the pool holds a single connection.
+func openOriginDB(dsn, origin string) (*gorm.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database config: %w", err)
+	}
+	sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SELECT pg_replication_origin_session_setup($1)", origin)
+		return err
+	}))
+	sqlDB.SetMaxOpenConns(1)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to connect to database as %s: %w", origin, err)
+	}
+	return db, nil
+}
 
-			log.Printf("Received event from PostgreSQL + Redis server: %v", event)
-			// Apply changes to local database
-			if err := c.applyChange(event); err != nil {
-				log.Printf("Error applying change from PostgreSQL + Redis server: %v", err)
+// close discards the open transaction and closes the applier's connection
+func (a *applier) close() {
+	a.rollback()
+	if sqlDB, err := a.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// apply applies event
+func (a *applier) apply(event *chat.DataChangeEvent) error {
+	// Relation events carry no position and are never applied, they only start
+	// the capture of local changes to the tables of the push upstream
+	if event.Operation == chat.Operation_OPERATION_RELATION {
+		if a.client.bidirectional && a.upstream == a.client.push {
+			return a.client.captureTable(event.Relation)
+		}
+		return nil
+	}
+	// Already applied before a reconnect or restart
+	if event.Position <= a.last {
+		return nil
+	}
+
+	switch event.Operation {
+	case chat.Operation_OPERATION_BEGIN:
+		a.snapshot = event.GetTransaction().GetSnapshot()
+		if !a.client.transactional {
+			return nil
+		}
+		a.rollback()
+		a.tx = a.db.Begin()
+		return a.tx.Error
+
+	case chat.Operation_OPERATION_COMMIT:
+		a.snapshot = false
+		if a.tx == nil {
+			if err := savePosition(a.db, a.upstream.Name, event); err != nil {
+				return err
 			}
+			a.last, a.cursor = event.Position, event.Cursor
+			return nil
 		}
-	}()
+		if err := savePosition(a.tx, a.upstream.Name, event); err != nil {
+			a.rollback()
+			return err
+		}
+		err := a.tx.Commit().Error
+		a.tx = nil
+		if err != nil {
+			return fmt.Errorf("failed to commit transaction %d: %w", event.GetTransaction().GetXid(), err)
+		}
+		a.last, a.cursor = event.Position, event.Cursor
+		return nil
+	}
 
-	// Wait for errors or context cancellation
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
+	if a.tx != nil {
+		if err := a.client.applyChange(a.tx, event); err != nil {
+			a.rollback()
+			return fmt.Errorf("transaction %d was rolled back: %w", event.GetTransaction().GetXid(), err)
+		}
+		return nil
+	}
 
-	select {
-	case err := <-errChan:
+	// Positions inside a snapshot cannot be resumed from, the client resumes from
+	// before the snapshot until its COMMIT is applied
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := a.client.applyChange(tx, event); err != nil {
+			return err
+		}
+		if a.snapshot {
+			return nil
+		}
+		return savePosition(tx, a.upstream.Name, event)
+	})
+	if err != nil || a.snapshot {
 		return err
-	case <-ctx.Done():
-		return ctx.Err()
+	}
+	a.last, a.cursor = event.Position, event.Cursor
+	return nil
+}
+
+// rollback discards the open transaction, if any
+func (a *applier) rollback() {
+	if a.tx != nil {
+		a.tx.Rollback()
+		a.tx = nil
 	}
 }
 
-func (c *Client) applyChange(event *chat.DataChangeEvent) error {
+// applyChange applies a change to the row it identifies by key. Inserts are upserts,
+// so applying an event twice is harmless, and updates and deletes never touch more
+// than one row.
+func (c *Client) applyChange(db *gorm.DB, event *chat.DataChangeEvent) error {
+	if c.bidirectional && event.Operation != chat.Operation_OPERATION_SCHEMA_CHANGE {
+		// Keeps the change out of the local capture. Rows of servers not stamping
+		// versions get one no push can be based on, so their pushes conflict.
+		version := event.RowVersion
+		if version == "" {
+			version = "unknown"
+		}
+		if err := db.Exec("SELECT set_config('syncer.version', ?, true)", version).Error; err != nil {
+			return fmt.Errorf("failed to set row version: %w", err)
+		}
+	}
+
 	switch event.Operation {
 	case chat.Operation_OPERATION_INSERT:
-		return c.db.Table(event.Table).Create(event.Data).Error
+		return upsertRow(db, event)
 	case chat.Operation_OPERATION_UPDATE:
-		return c.db.Table(event.Table).Where(event.OldData).Updates(event.Data).Error
+		return updateRow(db, event)
 	case chat.Operation_OPERATION_DELETE:
-		return c.db.Table(event.Table).Delete(event.Data).Error
+		return deleteRow(db, event)
+	case chat.Operation_OPERATION_SCHEMA_CHANGE:
+		if !c.applySchemaChanges {
+			log.Printf("Skipping schema change on %v: %s", event.SchemaChange.GetTables(), event.SchemaChange.GetDdl())
+			return nil
+		}
+		return db.Exec(event.SchemaChange.GetDdl()).Error
 	default:
 		return fmt.Errorf("unknown operation: %v", event.Operation)
 	}
 }
 
+// upsertRow inserts the new row, or overwrites the row with the same key. Without a
+// unique constraint on the key columns here, the row with the key is replaced.
+func upsertRow(db *gorm.DB, event *chat.DataChangeEvent) error {
+	values := rowValues(event.Data)
+	if len(event.KeyColumns) == 0 {
+		return db.Table(event.Table).Create(values).Error
+	}
+
+	conflict := clause.OnConflict{}
+	for _, column := range event.KeyColumns {
+		conflict.Columns = append(conflict.Columns, clause.Column{Name: column})
+	}
+	var updates []string
+	for column := range values {
+		if !slices.Contains(event.KeyColumns, column) {
+			updates = append(updates, column)
+		}
+	}
+	if len(updates) == 0 {
+		conflict.DoNothing = true
+	} else {
+		sort.Strings(updates)
+		conflict.DoUpdates = clause.AssignmentColumns(updates)
+	}
+	// A savepoint, so a failed ON CONFLICT leaves the transactional apply usable
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Table(event.Table).Clauses(conflict).Create(values).Error
+	})
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "42P10" {
+		return err
+	}
+
+	// 42P10: no unique constraint matches the key columns
+	key, err := rowKey(event, event.Data)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(event.Table).Where(key).Delete(map[string]interface{}{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 1 {
+			return fmt.Errorf("key %v matches %d rows in %s", key, result.RowsAffected, event.Table)
+		}
+		return tx.Table(event.Table).Create(values).Error
+	})
+}
+
+// updateRow updates the row with the old key, which the old row only carries when
+// the key changed
+func updateRow(db *gorm.DB, event *chat.DataChangeEvent) error {
+	old := event.OldData
+	if old == nil {
+		old = event.Data
+	}
+	key, err := rowKey(event, old)
+	if err != nil {
+		return err
+	}
+
+	// A savepoint inside the transactional apply, so an update matching several
+	// rows is undone
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(event.Table).Where(key).Updates(rowValues(event.Data))
+		if result.Error != nil {
+			return result.Error
+		}
+		switch {
+		case result.RowsAffected == 0:
+			return fmt.Errorf("no row in %s with key %v", event.Table, key)
+		case result.RowsAffected > 1:
+			return fmt.Errorf("key %v matches %d rows in %s", key, result.RowsAffected, event.Table)
+		}
+		return nil
+	})
+}
+
+// deleteRow deletes the row with the old key. Deleting a row that is already gone
+// is not an error.
+func deleteRow(db *gorm.DB, event *chat.DataChangeEvent) error {
+	key, err := rowKey(event, event.OldData)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(event.Table).Where(key).Delete(map[string]interface{}{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 1 {
+			return fmt.Errorf("key %v matches %d rows in %s", key, result.RowsAffected, event.Table)
+		}
+		return nil
+	})
+}
+
+// rowKey returns the key columns of row, failing rather than matching on less than
+// the whole key
+func rowKey(event *chat.DataChangeEvent, row *chat.Row) (map[string]interface{}, error) {
+	if len(event.KeyColumns) == 0 {
+		return nil, fmt.Errorf("%s has no key columns", event.Table)
+	}
+	values := rowValues(row)
+	key := make(map[string]interface{}, len(event.KeyColumns))
+	for _, column := range event.KeyColumns {
+		value, ok := values[column]
+		if !ok {
+			return nil, fmt.Errorf("%s change lacks key column %s", event.Table, column)
+		}
+		key[column] = value
+	}
+	return key, nil
+}
+
+// rowValues returns the values of a row keyed by column name
+func rowValues(row *chat.Row) map[string]interface{} {
+	values := make(map[string]interface{}, len(row.GetColumns()))
+	for _, column := range row.GetColumns() {
+		values[column.Name] = columnValue(column.GetValue())
+	}
+	return values
+}
+
+func columnValue(value *chat.Value) interface{} {
+	switch v := value.GetKind().(type) {
+	case *chat.Value_IntValue:
+		return v.IntValue
+	case *chat.Value_FloatValue:
+		return v.FloatValue
+	case *chat.Value_TextValue:
+		return v.TextValue
+	case *chat.Value_BoolValue:
+		return v.BoolValue
+	case *chat.Value_ByteaValue:
+		return v.ByteaValue
+	case *chat.Value_TimestampValue:
+		return v.TimestampValue.AsTime()
+	case *chat.Value_NumericValue:
+		return v.NumericValue
+	case *chat.Value_JsonValue:
+		return v.JsonValue
+	}
+	return nil
+}
+
+// captureTable installs the capture trigger on the push upstream's copy of a table, once per
+// key. Tables without a local copy or key columns are left alone.
+func (c *Client) captureTable(relation *chat.RelationEvent) error {
+	table := pgx.Identifier{relation.GetSchema(), relation.GetTable()}
+	var keys []string
+	for _, column := range relation.GetColumns() {
+		if column.Key {
+			keys = append(keys, "'"+strings.ReplaceAll(column.Name, "'", "''")+"'")
+		}
+	}
+	args := strings.Join(keys, ", ")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if captured, ok := c.captured[table.Sanitize()]; ok && captured == args {
+		return nil
+	}
+	if len(keys) == 0 {
+		log.Printf("Not capturing changes to %s, it has no key columns", table.Sanitize())
+		return nil
+	}
+
+	var exists bool
+	if err := c.push.db.Raw("SELECT to_regclass(?) IS NOT NULL", table.Sanitize()).Scan(&exists).Error; err != nil {
+		return fmt.Errorf("failed to look up %s: %w", table.Sanitize(), err)
+	}
+	if !exists {
+		return nil
+	}
+
+	err := c.push.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET LOCAL syncer.capture = off").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS syncer_capture ON %s", table.Sanitize())).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf(
+			"CREATE TRIGGER syncer_capture AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION syncer_capture_change(%s)",
+			table.Sanitize(), args)).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to capture changes to %s: %w", table.Sanitize(), err)
+	}
+	c.captured[table.Sanitize()] = args
+	log.Printf("Capturing local changes to %s", table.Sanitize())
+	return nil
+}
+
+// pushLoop pushes the outbox upstream until ctx is done. Changes made while the
+// server is unreachable wait in the outbox, and are replayed in order as soon as the
+// stream connects again.
+func (c *Client) pushLoop(ctx context.Context) {
+	ticker := time.NewTicker(pushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.connected:
+		}
+		for {
+			pushed, err := c.pushChanges(ctx)
+			if err != nil {
+				log.Printf("Error pushing local changes, keeping them in %s: %v", localChangesTable, err)
+				break
+			}
+			if pushed < pushBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// pushChanges pushes the oldest pending changes of the outbox to the push upstream
+// and records the outcome the server reported for each. It returns the
+// number of changes pushed. Changes pushed again after their outcome was lost are
+// recognised by id, so replaying them is harmless.
+func (c *Client) pushChanges(ctx context.Context) (int, error) {
+	var changes []localChange
+	err := c.push.db.Raw(fmt.Sprintf(`
+		SELECT id, table_name, operation, key_columns::text, old_row::text, new_row::text, base_version, changed_at
+		FROM %s WHERE status = 'pending' ORDER BY id LIMIT ?`, localChangesTable), pushBatchSize).Scan(&changes).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load local changes: %w", err)
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	stream, err := c.push.cli.PushChanges(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start pushing to %s: %w", c.push.Name, err)
+	}
+	for _, change := range changes {
+		push, err := change.request(c.origin)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode local change %d: %w", change.ID, err)
+		}
+		if err := stream.Send(push); err != nil {
+			return 0, fmt.Errorf("failed to push local change %d: %w", change.ID, err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, fmt.Errorf("failed to push local changes: %w", err)
+	}
+	results := resp.GetResults()
+	if len(results) > len(changes) {
+		results = results[:len(changes)]
+	}
+
+	err = c.push.db.Transaction(func(tx *gorm.DB) error {
+		for i, result := range results {
+			if err := acknowledge(tx, &changes[i], result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record outcome of local changes: %w", err)
+	}
+	return len(results), nil
+}
+
+// acknowledge records the outcome of a pushed change in the outbox and announces it
+// on the outbox channel
+func acknowledge(tx *gorm.DB, change *localChange, result *chat.ChangeResult) error {
+	status := strings.ToLower(strings.TrimPrefix(result.Status.String(), "CHANGE_STATUS_"))
+	if result.Status != chat.ChangeStatus_CHANGE_STATUS_APPLIED || result.Conflict {
+		log.Printf("Local change %d to %s: %s (conflict: %t) %s",
+			change.ID, change.Table, status, result.Conflict, result.Message)
+	}
+
+	err := tx.Exec(fmt.Sprintf(`
+		UPDATE %s SET status = ?, conflict = ?, row_version = ?, message = ?, acknowledged_at = now()
+		WHERE id = ?`, localChangesTable),
+		status, result.Conflict, result.RowVersion, result.Message, change.ID).Error
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":       change.ID,
+		"table":    change.Table,
+		"status":   status,
+		"conflict": result.Conflict,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Exec("SELECT pg_notify(?, ?)", outboxChannel, string(payload)).Error
+}
+
+// request builds the push of a captured change
+func (l *localChange) request(origin string) (*chat.PushChangesRequest, error) {
+	op, ok := chat.Operation_value["OPERATION_"+l.Operation]
+	if !ok {
+		return nil, fmt.Errorf("unknown operation %s", l.Operation)
+	}
+	change := &chat.DataChangeEvent{Operation: chat.Operation(op), Table: l.Table}
+	if err := json.Unmarshal([]byte(l.KeyColumns), &change.KeyColumns); err != nil {
+		return nil, fmt.Errorf("failed to decode key columns: %w", err)
+	}
+	var err error
+	if change.OldData, err = capturedRow(l.OldRow); err != nil {
+		return nil, err
+	}
+	if change.Data, err = capturedRow(l.NewRow); err != nil {
+		return nil, err
+	}
+
+	push := &chat.PushChangesRequest{
+		ChangeId:  l.ID,
+		Origin:    origin,
+		Change:    change,
+		ChangedAt: timestamppb.New(l.ChangedAt),
+	}
+	if l.BaseVersion != nil {
+		push.BaseVersion = *l.BaseVersion
+	}
+	return push, nil
+}
+
+// capturedRow converts a row captured with to_jsonb. Values keep their JSON form:
+// numbers travel as numeric, nested JSON re-encoded compactly.
+func capturedRow(data *string) (*chat.Row, error) {
+	if data == nil {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(*data)))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode captured row: %w", err)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	row := &chat.Row{Columns: make([]*chat.Column, 0, len(names))}
+	for _, name := range names {
+		value := &chat.Value{}
+		switch v := values[name].(type) {
+		case nil:
+			value.Kind = &chat.Value_NullValue{}
+		case string:
+			value.Kind = &chat.Value_TextValue{TextValue: v}
+		case json.Number:
+			value.Kind = &chat.Value_NumericValue{NumericValue: v.String()}
+		case bool:
+			value.Kind = &chat.Value_BoolValue{BoolValue: v}
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			value.Kind = &chat.Value_JsonValue{JsonValue: string(encoded)}
+		}
+		row.Columns = append(row.Columns, &chat.Column{Name: name, Value: value})
+	}
+	return row, nil
+}
+
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
@@ -161,7 +1239,7 @@ func main() {
 	}
 	defer client.Close()
 
-	// Stream changes from both servers
+	// Stream changes from every upstream
 	if err := client.StreamChanges(context.Background()); err != nil {
 		log.Fatalf("Error streaming changes: %v", err)
 	}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/conflict"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/core"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/events"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/replication"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/server"
+)
+
+// AppVersion is set at build time
+var AppVersion = "dev"
+
+const usage = `usage: syncer <command>
+
+Commands:
+  serve    stream the changes of the configured database to clients
+  version  print the version
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "serve":
+		if err := serve(); err != nil {
+			log.Fatal(err)
+		}
+	case "version":
+		fmt.Println(AppVersion)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// serve runs the server until it fails
+func serve() error {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Connect to PostgreSQL
+	db, err := gorm.Open(postgres.Open(cfg.GetPostgresDSN()), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Connect to the event bus
+	bus, err := events.NewBus(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create event bus: %w", err)
+	}
+	defer bus.Close()
+
+	// Checkpoints are kept on the bus if it can hold them, next to the replicated
+	// tables otherwise
+	checkpointer, ok := bus.(replication.Checkpointer)
+	if !ok {
+		checkpointer, err = replication.NewPostgresCheckpointer(db)
+		if err != nil {
+			return fmt.Errorf("failed to create checkpointer: %w", err)
+		}
+	}
+
+	// Create replication manager
+	replicator, err := replication.NewPostgresReplicator(cfg, checkpointer)
+	if err != nil {
+		return fmt.Errorf("failed to create replicator: %w", err)
+	}
+	defer replicator.Close()
+
+	// Setup replication
+	if err := replicator.SetupReplication(context.Background()); err != nil {
+		return fmt.Errorf("failed to setup replication: %w", err)
+	}
+
+	// Changes pushed by replicas are reconciled with the configured resolver
+	resolver, err := conflict.NewResolver(cfg.Conflicts.Resolver, cfg.Conflicts.OriginPriority)
+	if err != nil {
+		return fmt.Errorf("failed to create conflict resolver: %w", err)
+	}
+	pushes, err := replication.NewPushApplier(db, resolver, cfg.Conflicts.SourceOrigin, replicator.SystemID(), replicator.Masks())
+	if err != nil {
+		return fmt.Errorf("failed to create push applier: %w", err)
+	}
+
+	engine, err := core.NewEngine(cfg, db, replicator, bus, pushes)
+	if err != nil {
+		return fmt.Errorf("failed to create engine: %w", err)
+	}
+
+	// Create context for background tasks
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := engine.Start(ctx); err != nil {
+		return err
+	}
+
+	// Create gRPC server
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	// Administration is served apart from clients, on an address only reachable
+	// from the host by default
+	var adminLis net.Listener
+	if cfg.Server.AdminAddress != "" {
+		adminLis, err = net.Listen("tcp", cfg.Server.AdminAddress)
+		if err != nil {
+			lis.Close()
+			return fmt.Errorf("failed to listen for administration: %w", err)
+		}
+	}
+
+	s := grpc.NewServer()
+	chat.RegisterChatServiceServer(s, server.NewService(engine))
+	reflection.Register(s)
+
+	log.Printf("Server %s listening on port %d with the %s bus", cfg.Server.ID, cfg.Server.Port, cfg.Bus.Backend)
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- s.Serve(lis)
+	}()
+	if adminLis != nil {
+		admin := grpc.NewServer()
+		chat.RegisterAdminServiceServer(admin, server.NewAdminService(engine))
+		reflection.Register(admin)
+		defer admin.Stop()
+
+		log.Printf("Administration listening on %s", cfg.Server.AdminAddress)
+		go func() {
+			serveErr <- admin.Serve(adminLis)
+		}()
+	}
+
+	// Without replication the server would serve stale data, so it exits and is
+	// restarted from the last checkpoint
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			return fmt.Errorf("failed to serve: %w", err)
+		}
+		return nil
+	case err := <-engine.Failed():
+		s.Stop()
+		return err
+	}
+}
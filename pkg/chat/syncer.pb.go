@@ -0,0 +1,2632 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: syncer/v1/syncer.proto
+
+package chat
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The replica identity of a table, which decides what old rows carry.
+type ReplicaIdentity int32
+
+const (
+	ReplicaIdentity_REPLICA_IDENTITY_UNKNOWN ReplicaIdentity = 0
+	// The primary key.
+	ReplicaIdentity_REPLICA_IDENTITY_DEFAULT ReplicaIdentity = 1
+	// No old rows at all.
+	ReplicaIdentity_REPLICA_IDENTITY_NOTHING ReplicaIdentity = 2
+	// Every column.
+	ReplicaIdentity_REPLICA_IDENTITY_FULL ReplicaIdentity = 3
+	// The columns of a unique index.
+	ReplicaIdentity_REPLICA_IDENTITY_INDEX ReplicaIdentity = 4
+)
+
+// Enum value maps for ReplicaIdentity.
+var (
+	ReplicaIdentity_name = map[int32]string{
+		0: "REPLICA_IDENTITY_UNKNOWN",
+		1: "REPLICA_IDENTITY_DEFAULT",
+		2: "REPLICA_IDENTITY_NOTHING",
+		3: "REPLICA_IDENTITY_FULL",
+		4: "REPLICA_IDENTITY_INDEX",
+	}
+	ReplicaIdentity_value = map[string]int32{
+		"REPLICA_IDENTITY_UNKNOWN": 0,
+		"REPLICA_IDENTITY_DEFAULT": 1,
+		"REPLICA_IDENTITY_NOTHING": 2,
+		"REPLICA_IDENTITY_FULL":    3,
+		"REPLICA_IDENTITY_INDEX":   4,
+	}
+)
+
+func (x ReplicaIdentity) Enum() *ReplicaIdentity {
+	p := new(ReplicaIdentity)
+	*p = x
+	return p
+}
+
+func (x ReplicaIdentity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReplicaIdentity) Descriptor() protoreflect.EnumDescriptor {
+	return file_syncer_v1_syncer_proto_enumTypes[0].Descriptor()
+}
+
+func (ReplicaIdentity) Type() protoreflect.EnumType {
+	return &file_syncer_v1_syncer_proto_enumTypes[0]
+}
+
+func (x ReplicaIdentity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReplicaIdentity.Descriptor instead.
+func (ReplicaIdentity) EnumDescriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{0}
+}
+
+// The type of operation that caused the data change.
+type Operation int32
+
+const (
+	// Unknown operation.
+	Operation_OPERATION_UNKNOWN Operation = 0
+	// Insert operation.
+	Operation_OPERATION_INSERT Operation = 1
+	// Update operation.
+	Operation_OPERATION_UPDATE Operation = 2
+	// Delete operation.
+	Operation_OPERATION_DELETE Operation = 3
+	// Start of a transaction, followed by its row changes.
+	Operation_OPERATION_BEGIN Operation = 4
+	// End of a transaction.
+	Operation_OPERATION_COMMIT Operation = 5
+	// Table metadata, see RelationEvent.
+	Operation_OPERATION_RELATION Operation = 6
+	// A DDL statement, see SchemaChangeEvent.
+	Operation_OPERATION_SCHEMA_CHANGE Operation = 7
+)
+
+// Enum value maps for Operation.
+var (
+	Operation_name = map[int32]string{
+		0: "OPERATION_UNKNOWN",
+		1: "OPERATION_INSERT",
+		2: "OPERATION_UPDATE",
+		3: "OPERATION_DELETE",
+		4: "OPERATION_BEGIN",
+		5: "OPERATION_COMMIT",
+		6: "OPERATION_RELATION",
+		7: "OPERATION_SCHEMA_CHANGE",
+	}
+	Operation_value = map[string]int32{
+		"OPERATION_UNKNOWN":       0,
+		"OPERATION_INSERT":        1,
+		"OPERATION_UPDATE":        2,
+		"OPERATION_DELETE":        3,
+		"OPERATION_BEGIN":         4,
+		"OPERATION_COMMIT":        5,
+		"OPERATION_RELATION":      6,
+		"OPERATION_SCHEMA_CHANGE": 7,
+	}
+)
+
+func (x Operation) Enum() *Operation {
+	p := new(Operation)
+	*p = x
+	return p
+}
+
+func (x Operation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Operation) Descriptor() protoreflect.EnumDescriptor {
+	return file_syncer_v1_syncer_proto_enumTypes[1].Descriptor()
+}
+
+func (Operation) Type() protoreflect.EnumType {
+	return &file_syncer_v1_syncer_proto_enumTypes[1]
+}
+
+func (x Operation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Operation.Descriptor instead.
+func (Operation) EnumDescriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{1}
+}
+
+type ChangeStatus int32
+
+const (
+	ChangeStatus_CHANGE_STATUS_UNKNOWN ChangeStatus = 0
+	// The change was applied as pushed.
+	ChangeStatus_CHANGE_STATUS_APPLIED ChangeStatus = 1
+	// The change was merged with the row on the source.
+	ChangeStatus_CHANGE_STATUS_MERGED ChangeStatus = 2
+	// The row on the source was kept and the change dropped.
+	ChangeStatus_CHANGE_STATUS_REJECTED ChangeStatus = 3
+	// The conflict was recorded for manual review and the row left as it is.
+	ChangeStatus_CHANGE_STATUS_PARKED ChangeStatus = 4
+	// The source rejected the change, which is invalid or violates a constraint.
+	// Changes the source cannot apply for now end the push with UNAVAILABLE
+	// instead, to be pushed again.
+	ChangeStatus_CHANGE_STATUS_FAILED ChangeStatus = 5
+)
+
+// Enum value maps for ChangeStatus.
+var (
+	ChangeStatus_name = map[int32]string{
+		0: "CHANGE_STATUS_UNKNOWN",
+		1: "CHANGE_STATUS_APPLIED",
+		2: "CHANGE_STATUS_MERGED",
+		3: "CHANGE_STATUS_REJECTED",
+		4: "CHANGE_STATUS_PARKED",
+		5: "CHANGE_STATUS_FAILED",
+	}
+	ChangeStatus_value = map[string]int32{
+		"CHANGE_STATUS_UNKNOWN":  0,
+		"CHANGE_STATUS_APPLIED":  1,
+		"CHANGE_STATUS_MERGED":   2,
+		"CHANGE_STATUS_REJECTED": 3,
+		"CHANGE_STATUS_PARKED":   4,
+		"CHANGE_STATUS_FAILED":   5,
+	}
+)
+
+func (x ChangeStatus) Enum() *ChangeStatus {
+	p := new(ChangeStatus)
+	*p = x
+	return p
+}
+
+func (x ChangeStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_syncer_v1_syncer_proto_enumTypes[2].Descriptor()
+}
+
+func (ChangeStatus) Type() protoreflect.EnumType {
+	return &file_syncer_v1_syncer_proto_enumTypes[2]
+}
+
+func (x ChangeStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeStatus.Descriptor instead.
+func (ChangeStatus) EnumDescriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{2}
+}
+
+type MutationStatus int32
+
+const (
+	MutationStatus_MUTATION_STATUS_UNKNOWN MutationStatus = 0
+	// The mutation applied.
+	MutationStatus_MUTATION_STATUS_APPLIED MutationStatus = 1
+	// No row matched the key.
+	MutationStatus_MUTATION_STATUS_NOT_FOUND MutationStatus = 2
+	// The row no longer has the expected version.
+	MutationStatus_MUTATION_STATUS_VERSION_MISMATCH MutationStatus = 3
+	// The mutation is invalid or the write failed.
+	MutationStatus_MUTATION_STATUS_FAILED MutationStatus = 4
+	// The mutation was rolled back because another one did not apply.
+	MutationStatus_MUTATION_STATUS_ABORTED MutationStatus = 5
+)
+
+// Enum value maps for MutationStatus.
+var (
+	MutationStatus_name = map[int32]string{
+		0: "MUTATION_STATUS_UNKNOWN",
+		1: "MUTATION_STATUS_APPLIED",
+		2: "MUTATION_STATUS_NOT_FOUND",
+		3: "MUTATION_STATUS_VERSION_MISMATCH",
+		4: "MUTATION_STATUS_FAILED",
+		5: "MUTATION_STATUS_ABORTED",
+	}
+	MutationStatus_value = map[string]int32{
+		"MUTATION_STATUS_UNKNOWN":          0,
+		"MUTATION_STATUS_APPLIED":          1,
+		"MUTATION_STATUS_NOT_FOUND":        2,
+		"MUTATION_STATUS_VERSION_MISMATCH": 3,
+		"MUTATION_STATUS_FAILED":           4,
+		"MUTATION_STATUS_ABORTED":          5,
+	}
+)
+
+func (x MutationStatus) Enum() *MutationStatus {
+	p := new(MutationStatus)
+	*p = x
+	return p
+}
+
+func (x MutationStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MutationStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_syncer_v1_syncer_proto_enumTypes[3].Descriptor()
+}
+
+func (MutationStatus) Type() protoreflect.EnumType {
+	return &file_syncer_v1_syncer_proto_enumTypes[3]
+}
+
+func (x MutationStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MutationStatus.Descriptor instead.
+func (MutationStatus) EnumDescriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{3}
+}
+
+// Request to start streaming data changes.
+type StreamDataChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional filter for specific tables, as schema-qualified names or glob
+	// patterns such as "public.order_*". Unqualified names refer to the public
+	// schema. Names that match no published table are rejected.
+	Tables []string `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
+	// Optional position of the last event the client applied. The stream resumes
+	// with the first event after it.
+	ResumePosition string `protobuf:"bytes,2,opt,name=resume_position,json=resumePosition,proto3" json:"resume_position,omitempty"`
+	// Stream a consistent snapshot of every published table as INSERT events
+	// before the changes. Ignored when resume_position is set.
+	InitialSnapshot bool `protobuf:"varint,3,opt,name=initial_snapshot,json=initialSnapshot,proto3" json:"initial_snapshot,omitempty"`
+	// Optional row predicates keyed by table name, such as "tenant_id = 42" or
+	// "status IN ('open', 'pending')". Only rows matching the predicate of their
+	// table are streamed. Updates that move a row into or out of the predicate
+	// arrive as INSERT or DELETE events. The tables must have REPLICA IDENTITY
+	// FULL, since the old row of deletes and updates must show whether the row
+	// matched; filters on other tables are refused with INVALID_ARGUMENT.
+	RowFilters map[string]string `protobuf:"bytes,4,rep,name=row_filters,json=rowFilters,proto3" json:"row_filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Optional columns to stream per table, keyed by table name. Tables without an
+	// entry are streamed with all of their columns. The key columns are always
+	// streamed, so that updates and deletes can be applied.
+	Columns map[string]*ColumnList `protobuf:"bytes,5,rep,name=columns,proto3" json:"columns,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Optional id the client goes by, as returned by Connect.
+	ClientId string `protobuf:"bytes,6,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// The session token returned by Connect. Streams without one get a session of
+	// their own, which ends with the stream.
+	SessionToken string `protobuf:"bytes,7,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	// Optional cursor of the last event the client applied. On a bus keeping
+	// events, the stream resumes with the first event after it, even once the
+	// server no longer holds it in memory. Takes precedence over resume_position
+	// as long as the bus still holds the event; ignored by other buses.
+	ResumeCursor  string `protobuf:"bytes,8,opt,name=resume_cursor,json=resumeCursor,proto3" json:"resume_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamDataChangesRequest) Reset() {
+	*x = StreamDataChangesRequest{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamDataChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDataChangesRequest) ProtoMessage() {}
+
+func (x *StreamDataChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDataChangesRequest.ProtoReflect.Descriptor instead.
+func (*StreamDataChangesRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamDataChangesRequest) GetTables() []string {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+func (x *StreamDataChangesRequest) GetResumePosition() string {
+	if x != nil {
+		return x.ResumePosition
+	}
+	return ""
+}
+
+func (x *StreamDataChangesRequest) GetInitialSnapshot() bool {
+	if x != nil {
+		return x.InitialSnapshot
+	}
+	return false
+}
+
+func (x *StreamDataChangesRequest) GetRowFilters() map[string]string {
+	if x != nil {
+		return x.RowFilters
+	}
+	return nil
+}
+
+func (x *StreamDataChangesRequest) GetColumns() map[string]*ColumnList {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *StreamDataChangesRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *StreamDataChangesRequest) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *StreamDataChangesRequest) GetResumeCursor() string {
+	if x != nil {
+		return x.ResumeCursor
+	}
+	return ""
+}
+
+type SyncDataChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*SyncDataChangesRequest_Start
+	//	*SyncDataChangesRequest_Ack
+	Message       isSyncDataChangesRequest_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncDataChangesRequest) Reset() {
+	*x = SyncDataChangesRequest{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncDataChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncDataChangesRequest) ProtoMessage() {}
+
+func (x *SyncDataChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncDataChangesRequest.ProtoReflect.Descriptor instead.
+func (*SyncDataChangesRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SyncDataChangesRequest) GetMessage() isSyncDataChangesRequest_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *SyncDataChangesRequest) GetStart() *StreamDataChangesRequest {
+	if x != nil {
+		if x, ok := x.Message.(*SyncDataChangesRequest_Start); ok {
+			return x.Start
+		}
+	}
+	return nil
+}
+
+func (x *SyncDataChangesRequest) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Message.(*SyncDataChangesRequest_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+type isSyncDataChangesRequest_Message interface {
+	isSyncDataChangesRequest_Message()
+}
+
+type SyncDataChangesRequest_Start struct {
+	// Starts the stream, in the first message only.
+	Start *StreamDataChangesRequest `protobuf:"bytes,1,opt,name=start,proto3,oneof"`
+}
+
+type SyncDataChangesRequest_Ack struct {
+	Ack *Ack `protobuf:"bytes,2,opt,name=ack,proto3,oneof"`
+}
+
+func (*SyncDataChangesRequest_Start) isSyncDataChangesRequest_Message() {}
+
+func (*SyncDataChangesRequest_Ack) isSyncDataChangesRequest_Message() {}
+
+// Acknowledges the changes a client applied and grants it credit. Nothing is sent
+// before the first ack.
+type Ack struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The position of the last event the client durably applied. The server keeps
+	// the changes after it until the client acknowledges them. Inside an initial
+	// snapshot, which cannot be resumed from, the last snapshot row applied.
+	AppliedPosition string `protobuf:"bytes,1,opt,name=applied_position,json=appliedPosition,proto3" json:"applied_position,omitempty"`
+	// How many events the server may send after the applied position. A
+	// transaction is sent whole once started, even past the window, but the
+	// initial snapshot takes credit row by row.
+	Window        uint32 `protobuf:"varint,2,opt,name=window,proto3" json:"window,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Ack) GetAppliedPosition() string {
+	if x != nil {
+		return x.AppliedPosition
+	}
+	return ""
+}
+
+func (x *Ack) GetWindow() uint32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+type ConnectRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifies the client across reconnects, empty to have the server assign
+	// an id.
+	ClientId string `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// The origin of the changes the client pushes, if it pushes any.
+	Origin string `protobuf:"bytes,2,opt,name=origin,proto3" json:"origin,omitempty"`
+	// The features the client makes use of, such as "snapshot" or "push".
+	Capabilities []string `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// The highest protocol version the client speaks, 0 for version 1.
+	ProtocolVersion uint32 `protobuf:"varint,4,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// The position of the last event the client applied, if any.
+	Cursor string `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// The token of the session the client had before reconnecting. It lets the
+	// client take over its id while the server still counts it as streaming.
+	SessionToken  string `protobuf:"bytes,6,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ConnectRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *ConnectRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *ConnectRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+type ConnectResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The id the client goes by, as requested or assigned.
+	ClientId string `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// Names the server instance.
+	ServerId string `protobuf:"bytes,2,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	// The event bus the server runs on, such as "memory" or "redis".
+	Backend string `protobuf:"bytes,3,opt,name=backend,proto3" json:"backend,omitempty"`
+	// The tables the server publishes, schema-qualified.
+	PublishedTables []string `protobuf:"bytes,4,rep,name=published_tables,json=publishedTables,proto3" json:"published_tables,omitempty"`
+	// Identifies the session on the streams of the client.
+	SessionToken string `protobuf:"bytes,5,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	// The protocol version agreed on, the lower of the client's and the server's.
+	ProtocolVersion uint32 `protobuf:"varint,6,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// The capabilities of the client the server supports.
+	Capabilities  []string `protobuf:"bytes,7,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectResponse) Reset() {
+	*x = ConnectResponse{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectResponse) ProtoMessage() {}
+
+func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConnectResponse) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ConnectResponse) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *ConnectResponse) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *ConnectResponse) GetPublishedTables() []string {
+	if x != nil {
+		return x.PublishedTables
+	}
+	return nil
+}
+
+func (x *ConnectResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *ConnectResponse) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *ConnectResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{5}
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+// A client connected to the server.
+type Session struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ClientId        string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Origin          string                 `protobuf:"bytes,2,opt,name=origin,proto3" json:"origin,omitempty"`
+	Capabilities    []string               `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	ProtocolVersion uint32                 `protobuf:"varint,4,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	ConnectedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=connected_at,json=connectedAt,proto3" json:"connected_at,omitempty"`
+	// Whether the client is streaming changes.
+	Streaming bool `protobuf:"varint,6,opt,name=streaming,proto3" json:"streaming,omitempty"`
+	// The filters of the stream, as requested.
+	Tables     []string               `protobuf:"bytes,7,rep,name=tables,proto3" json:"tables,omitempty"`
+	RowFilters map[string]string      `protobuf:"bytes,8,rep,name=row_filters,json=rowFilters,proto3" json:"row_filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Columns    map[string]*ColumnList `protobuf:"bytes,9,rep,name=columns,proto3" json:"columns,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// The position of the last event sent to the client, or the cursor it
+	// connected with until then.
+	Cursor string `protobuf:"bytes,10,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// The events waiting to be sent to the client, in memory or spilled to disk.
+	QueueDepth uint64 `protobuf:"varint,11,opt,name=queue_depth,json=queueDepth,proto3" json:"queue_depth,omitempty"`
+	// How far the last event sent to the client is behind the newest event, by
+	// commit time.
+	Lag *durationpb.Duration `protobuf:"bytes,12,opt,name=lag,proto3" json:"lag,omitempty"`
+	// The position the client last acknowledged as applied, on flow controlled
+	// streams.
+	AckedCursor string `protobuf:"bytes,13,opt,name=acked_cursor,json=ackedCursor,proto3" json:"acked_cursor,omitempty"`
+	// The credit window the client last granted, on flow controlled streams.
+	Window        uint32 `protobuf:"varint,14,opt,name=window,proto3" json:"window,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Session) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Session) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *Session) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Session) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *Session) GetConnectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConnectedAt
+	}
+	return nil
+}
+
+func (x *Session) GetStreaming() bool {
+	if x != nil {
+		return x.Streaming
+	}
+	return false
+}
+
+func (x *Session) GetTables() []string {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+func (x *Session) GetRowFilters() map[string]string {
+	if x != nil {
+		return x.RowFilters
+	}
+	return nil
+}
+
+func (x *Session) GetColumns() map[string]*ColumnList {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *Session) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *Session) GetQueueDepth() uint64 {
+	if x != nil {
+		return x.QueueDepth
+	}
+	return 0
+}
+
+func (x *Session) GetLag() *durationpb.Duration {
+	if x != nil {
+		return x.Lag
+	}
+	return nil
+}
+
+func (x *Session) GetAckedCursor() string {
+	if x != nil {
+		return x.AckedCursor
+	}
+	return ""
+}
+
+func (x *Session) GetWindow() uint32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+// A list of column names.
+type ColumnList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColumnList) Reset() {
+	*x = ColumnList{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColumnList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColumnList) ProtoMessage() {}
+
+func (x *ColumnList) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColumnList.ProtoReflect.Descriptor instead.
+func (*ColumnList) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ColumnList) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+// Represents a data change event.
+type DataChangeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The type of operation that caused the change.
+	Operation Operation `protobuf:"varint,1,opt,name=operation,proto3,enum=syncer.v1.Operation" json:"operation,omitempty"`
+	// The name of the table that was changed.
+	Table string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	// The timestamp when the change occurred.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Opaque position of the event in the change stream. Positions increase
+	// monotonically and compare in byte order. Set on every event but RELATION.
+	Position string `protobuf:"bytes,6,opt,name=position,proto3" json:"position,omitempty"`
+	// The transaction being framed, set on BEGIN and COMMIT events.
+	Transaction *Transaction `protobuf:"bytes,7,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	// The new row after the change (for inserts and updates).
+	Data *Row `protobuf:"bytes,8,opt,name=data,proto3" json:"data,omitempty"`
+	// The old row before the change (for updates and deletes). Unless the table
+	// has REPLICA IDENTITY FULL, it only holds the key columns, and updates only
+	// carry it when the key changed.
+	OldData *Row `protobuf:"bytes,9,opt,name=old_data,json=oldData,proto3" json:"old_data,omitempty"`
+	// The names of the columns identifying the row: the primary key, else the
+	// replica identity index. Tables with neither are keyed by all columns under
+	// REPLICA IDENTITY FULL, and by none otherwise.
+	KeyColumns []string `protobuf:"bytes,10,rep,name=key_columns,json=keyColumns,proto3" json:"key_columns,omitempty"`
+	// The table described, set on RELATION events.
+	Relation *RelationEvent `protobuf:"bytes,11,opt,name=relation,proto3" json:"relation,omitempty"`
+	// The id of the relation describing the table of a row change.
+	RelationId uint32 `protobuf:"varint,12,opt,name=relation_id,json=relationId,proto3" json:"relation_id,omitempty"`
+	// The DDL statement, set on SCHEMA_CHANGE events.
+	SchemaChange *SchemaChangeEvent `protobuf:"bytes,13,opt,name=schema_change,json=schemaChange,proto3" json:"schema_change,omitempty"`
+	// The version of the row after the change, naming the transaction that last
+	// wrote it on the source: the source's system identifier and the 64-bit
+	// transaction id, "<system>:<xid>". Set on row changes.
+	RowVersion string `protobuf:"bytes,14,opt,name=row_version,json=rowVersion,proto3" json:"row_version,omitempty"`
+	// The replication origin the source applied the transaction on behalf of,
+	// empty for changes made on the source itself.
+	Origin string `protobuf:"bytes,15,opt,name=origin,proto3" json:"origin,omitempty"`
+	// Where the event is kept on the event bus, such as its Redis stream id, if
+	// the bus keeps events. Clients can resume from it.
+	Cursor        string `protobuf:"bytes,16,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataChangeEvent) Reset() {
+	*x = DataChangeEvent{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataChangeEvent) ProtoMessage() {}
+
+func (x *DataChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataChangeEvent.ProtoReflect.Descriptor instead.
+func (*DataChangeEvent) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DataChangeEvent) GetOperation() Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return Operation_OPERATION_UNKNOWN
+}
+
+func (x *DataChangeEvent) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *DataChangeEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetPosition() string {
+	if x != nil {
+		return x.Position
+	}
+	return ""
+}
+
+func (x *DataChangeEvent) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetData() *Row {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetOldData() *Row {
+	if x != nil {
+		return x.OldData
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetKeyColumns() []string {
+	if x != nil {
+		return x.KeyColumns
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetRelation() *RelationEvent {
+	if x != nil {
+		return x.Relation
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetRelationId() uint32 {
+	if x != nil {
+		return x.RelationId
+	}
+	return 0
+}
+
+func (x *DataChangeEvent) GetSchemaChange() *SchemaChangeEvent {
+	if x != nil {
+		return x.SchemaChange
+	}
+	return nil
+}
+
+func (x *DataChangeEvent) GetRowVersion() string {
+	if x != nil {
+		return x.RowVersion
+	}
+	return ""
+}
+
+func (x *DataChangeEvent) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *DataChangeEvent) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+// A DDL statement executed on the source. The row changes that depend on it
+// follow it in the stream.
+type SchemaChangeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The command tag, such as "ALTER TABLE".
+	CommandTag string `protobuf:"bytes,1,opt,name=command_tag,json=commandTag,proto3" json:"command_tag,omitempty"`
+	// The schema-qualified tables the statement created, altered or dropped.
+	Tables []string `protobuf:"bytes,2,rep,name=tables,proto3" json:"tables,omitempty"`
+	// The statement as the source received it, which may hold several statements.
+	Ddl           string `protobuf:"bytes,3,opt,name=ddl,proto3" json:"ddl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaChangeEvent) Reset() {
+	*x = SchemaChangeEvent{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaChangeEvent) ProtoMessage() {}
+
+func (x *SchemaChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaChangeEvent.ProtoReflect.Descriptor instead.
+func (*SchemaChangeEvent) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SchemaChangeEvent) GetCommandTag() string {
+	if x != nil {
+		return x.CommandTag
+	}
+	return ""
+}
+
+func (x *SchemaChangeEvent) GetTables() []string {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+func (x *SchemaChangeEvent) GetDdl() string {
+	if x != nil {
+		return x.Ddl
+	}
+	return ""
+}
+
+// Describes the shape of a table, mirroring the pgoutput Relation message. A
+// subscriber receives it before the first row change of the table, and again
+// after the table changed. The server keeps the relations it reads from the
+// stream, positioned where they occurred, and sends each subscriber a copy ahead
+// of the row change needing it, so relation events reaching clients carry no
+// position: clients resume from the row changes around them.
+type RelationEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The table OID, which row changes refer to as relation_id.
+	Id              uint32            `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Schema          string            `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	Table           string            `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	Columns         []*RelationColumn `protobuf:"bytes,4,rep,name=columns,proto3" json:"columns,omitempty"`
+	ReplicaIdentity ReplicaIdentity   `protobuf:"varint,5,opt,name=replica_identity,json=replicaIdentity,proto3,enum=syncer.v1.ReplicaIdentity" json:"replica_identity,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RelationEvent) Reset() {
+	*x = RelationEvent{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelationEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationEvent) ProtoMessage() {}
+
+func (x *RelationEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationEvent.ProtoReflect.Descriptor instead.
+func (*RelationEvent) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RelationEvent) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RelationEvent) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *RelationEvent) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *RelationEvent) GetColumns() []*RelationColumn {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *RelationEvent) GetReplicaIdentity() ReplicaIdentity {
+	if x != nil {
+		return x.ReplicaIdentity
+	}
+	return ReplicaIdentity_REPLICA_IDENTITY_UNKNOWN
+}
+
+// A column of a relation.
+type RelationColumn struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The Postgres type OID of the column.
+	TypeOid uint32 `protobuf:"varint,2,opt,name=type_oid,json=typeOid,proto3" json:"type_oid,omitempty"`
+	// The type modifier, such as the length of varchar(n), or -1 if there is none.
+	TypeModifier int32 `protobuf:"varint,3,opt,name=type_modifier,json=typeModifier,proto3" json:"type_modifier,omitempty"`
+	// The type as written in SQL, such as "character varying(64)".
+	TypeName string `protobuf:"bytes,4,opt,name=type_name,json=typeName,proto3" json:"type_name,omitempty"`
+	Nullable bool   `protobuf:"varint,5,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	// Whether the column is one of the key_columns of the table's row changes.
+	Key           bool `protobuf:"varint,6,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelationColumn) Reset() {
+	*x = RelationColumn{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelationColumn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationColumn) ProtoMessage() {}
+
+func (x *RelationColumn) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationColumn.ProtoReflect.Descriptor instead.
+func (*RelationColumn) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RelationColumn) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RelationColumn) GetTypeOid() uint32 {
+	if x != nil {
+		return x.TypeOid
+	}
+	return 0
+}
+
+func (x *RelationColumn) GetTypeModifier() int32 {
+	if x != nil {
+		return x.TypeModifier
+	}
+	return 0
+}
+
+func (x *RelationColumn) GetTypeName() string {
+	if x != nil {
+		return x.TypeName
+	}
+	return ""
+}
+
+func (x *RelationColumn) GetNullable() bool {
+	if x != nil {
+		return x.Nullable
+	}
+	return false
+}
+
+func (x *RelationColumn) GetKey() bool {
+	if x != nil {
+		return x.Key
+	}
+	return false
+}
+
+// A row image. Unchanged TOAST values are left out.
+type Row struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Columns       []*Column              `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Row) Reset() {
+	*x = Row{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Row) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Row) ProtoMessage() {}
+
+func (x *Row) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Row.ProtoReflect.Descriptor instead.
+func (*Row) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Row) GetColumns() []*Column {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+// A column of a row image.
+type Column struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// The Postgres type OID of the column.
+	TypeOid       uint32 `protobuf:"varint,2,opt,name=type_oid,json=typeOid,proto3" json:"type_oid,omitempty"`
+	Value         *Value `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Column) Reset() {
+	*x = Column{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Column) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Column) ProtoMessage() {}
+
+func (x *Column) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Column.ProtoReflect.Descriptor instead.
+func (*Column) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Column) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Column) GetTypeOid() uint32 {
+	if x != nil {
+		return x.TypeOid
+	}
+	return 0
+}
+
+func (x *Column) GetValue() *Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// A typed column value.
+type Value struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Value_NullValue
+	//	*Value_IntValue
+	//	*Value_FloatValue
+	//	*Value_TextValue
+	//	*Value_BoolValue
+	//	*Value_ByteaValue
+	//	*Value_TimestampValue
+	//	*Value_NumericValue
+	//	*Value_JsonValue
+	Kind          isValue_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Value) GetKind() isValue_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Value) GetNullValue() structpb.NullValue {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_NullValue); ok {
+			return x.NullValue
+		}
+	}
+	return structpb.NullValue(0)
+}
+
+func (x *Value) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetFloatValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_FloatValue); ok {
+			return x.FloatValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetTextValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_TextValue); ok {
+			return x.TextValue
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *Value) GetByteaValue() []byte {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_ByteaValue); ok {
+			return x.ByteaValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetTimestampValue() *timestamppb.Timestamp {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_TimestampValue); ok {
+			return x.TimestampValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetNumericValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_NumericValue); ok {
+			return x.NumericValue
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetJsonValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_JsonValue); ok {
+			return x.JsonValue
+		}
+	}
+	return ""
+}
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_NullValue struct {
+	// SQL NULL.
+	NullValue structpb.NullValue `protobuf:"varint,1,opt,name=null_value,json=nullValue,proto3,enum=google.protobuf.NullValue,oneof"`
+}
+
+type Value_IntValue struct {
+	// smallint, integer, bigint and oid.
+	IntValue int64 `protobuf:"varint,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_FloatValue struct {
+	// real and double precision.
+	FloatValue float64 `protobuf:"fixed64,3,opt,name=float_value,json=floatValue,proto3,oneof"`
+}
+
+type Value_TextValue struct {
+	// Text and any type without a dedicated kind, in its Postgres text form.
+	TextValue string `protobuf:"bytes,4,opt,name=text_value,json=textValue,proto3,oneof"`
+}
+
+type Value_BoolValue struct {
+	BoolValue bool `protobuf:"varint,5,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type Value_ByteaValue struct {
+	ByteaValue []byte `protobuf:"bytes,6,opt,name=bytea_value,json=byteaValue,proto3,oneof"`
+}
+
+type Value_TimestampValue struct {
+	// timestamp, timestamptz and date. Timestamps without time zone are in UTC.
+	TimestampValue *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp_value,json=timestampValue,proto3,oneof"`
+}
+
+type Value_NumericValue struct {
+	// numeric, in its text form to keep its precision.
+	NumericValue string `protobuf:"bytes,8,opt,name=numeric_value,json=numericValue,proto3,oneof"`
+}
+
+type Value_JsonValue struct {
+	// json and jsonb, as JSON text.
+	JsonValue string `protobuf:"bytes,9,opt,name=json_value,json=jsonValue,proto3,oneof"`
+}
+
+func (*Value_NullValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_FloatValue) isValue_Kind() {}
+
+func (*Value_TextValue) isValue_Kind() {}
+
+func (*Value_BoolValue) isValue_Kind() {}
+
+func (*Value_ByteaValue) isValue_Kind() {}
+
+func (*Value_TimestampValue) isValue_Kind() {}
+
+func (*Value_NumericValue) isValue_Kind() {}
+
+func (*Value_JsonValue) isValue_Kind() {}
+
+// Describes the Postgres transaction that a group of row changes belongs to.
+type Transaction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The transaction id.
+	Xid uint32 `protobuf:"varint,1,opt,name=xid,proto3" json:"xid,omitempty"`
+	// The LSN of the commit record.
+	CommitLsn string `protobuf:"bytes,2,opt,name=commit_lsn,json=commitLsn,proto3" json:"commit_lsn,omitempty"`
+	// The commit timestamp.
+	CommitTimestamp *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=commit_timestamp,json=commitTimestamp,proto3" json:"commit_timestamp,omitempty"`
+	// The number of row changes in the transaction, set on COMMIT.
+	RowCount uint32 `protobuf:"varint,4,opt,name=row_count,json=rowCount,proto3" json:"row_count,omitempty"`
+	// Whether this is the synthetic transaction carrying the initial snapshot.
+	Snapshot      bool `protobuf:"varint,5,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Transaction) GetXid() uint32 {
+	if x != nil {
+		return x.Xid
+	}
+	return 0
+}
+
+func (x *Transaction) GetCommitLsn() string {
+	if x != nil {
+		return x.CommitLsn
+	}
+	return ""
+}
+
+func (x *Transaction) GetCommitTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CommitTimestamp
+	}
+	return nil
+}
+
+func (x *Transaction) GetRowCount() uint32 {
+	if x != nil {
+		return x.RowCount
+	}
+	return 0
+}
+
+func (x *Transaction) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+// A change made on a replica.
+type PushChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifies the replica the change was made on.
+	Origin string `protobuf:"bytes,1,opt,name=origin,proto3" json:"origin,omitempty"`
+	// The change, with the table, key columns and the rows before and after it.
+	Change *DataChangeEvent `protobuf:"bytes,2,opt,name=change,proto3" json:"change,omitempty"`
+	// The row_version the replica last received for the row, empty if it never
+	// received the row. The change conflicts if the row moved on since.
+	BaseVersion string `protobuf:"bytes,3,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`
+	// When the change was made on the replica.
+	ChangedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	// Identifies the change among those of its origin. A change pushed again
+	// after its result was lost is not applied twice; its first result is
+	// returned instead.
+	ChangeId      uint64 `protobuf:"varint,5,opt,name=change_id,json=changeId,proto3" json:"change_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushChangesRequest) Reset() {
+	*x = PushChangesRequest{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushChangesRequest) ProtoMessage() {}
+
+func (x *PushChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushChangesRequest.ProtoReflect.Descriptor instead.
+func (*PushChangesRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PushChangesRequest) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *PushChangesRequest) GetChange() *DataChangeEvent {
+	if x != nil {
+		return x.Change
+	}
+	return nil
+}
+
+func (x *PushChangesRequest) GetBaseVersion() string {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return ""
+}
+
+func (x *PushChangesRequest) GetChangedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return nil
+}
+
+func (x *PushChangesRequest) GetChangeId() uint64 {
+	if x != nil {
+		return x.ChangeId
+	}
+	return 0
+}
+
+type PushChangesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The outcome of each pushed change, in the order they were pushed.
+	Results       []*ChangeResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushChangesResponse) Reset() {
+	*x = PushChangesResponse{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushChangesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushChangesResponse) ProtoMessage() {}
+
+func (x *PushChangesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushChangesResponse.ProtoReflect.Descriptor instead.
+func (*PushChangesResponse) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PushChangesResponse) GetResults() []*ChangeResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// The outcome of a pushed change.
+type ChangeResult struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status ChangeStatus           `protobuf:"varint,1,opt,name=status,proto3,enum=syncer.v1.ChangeStatus" json:"status,omitempty"`
+	// Whether the change conflicted with the row on the source.
+	Conflict bool `protobuf:"varint,2,opt,name=conflict,proto3" json:"conflict,omitempty"`
+	// The version of the row after the change, if it was written.
+	RowVersion string `protobuf:"bytes,3,opt,name=row_version,json=rowVersion,proto3" json:"row_version,omitempty"`
+	// Why the change failed or was not applied as pushed. Columns the server
+	// masks are never written, the message of changes setting them names them.
+	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeResult) Reset() {
+	*x = ChangeResult{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeResult) ProtoMessage() {}
+
+func (x *ChangeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeResult.ProtoReflect.Descriptor instead.
+func (*ChangeResult) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ChangeResult) GetStatus() ChangeStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ChangeStatus_CHANGE_STATUS_UNKNOWN
+}
+
+func (x *ChangeResult) GetConflict() bool {
+	if x != nil {
+		return x.Conflict
+	}
+	return false
+}
+
+func (x *ChangeResult) GetRowVersion() string {
+	if x != nil {
+		return x.RowVersion
+	}
+	return ""
+}
+
+func (x *ChangeResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SubmitChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Applied in order, all or none of them.
+	Mutations     []*Mutation `protobuf:"bytes,1,rep,name=mutations,proto3" json:"mutations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitChangesRequest) Reset() {
+	*x = SubmitChangesRequest{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitChangesRequest) ProtoMessage() {}
+
+func (x *SubmitChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitChangesRequest.ProtoReflect.Descriptor instead.
+func (*SubmitChangesRequest) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SubmitChangesRequest) GetMutations() []*Mutation {
+	if x != nil {
+		return x.Mutations
+	}
+	return nil
+}
+
+// An insert, update or delete of a single row.
+type Mutation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// INSERT, UPDATE or DELETE.
+	Operation Operation `protobuf:"varint,1,opt,name=operation,proto3,enum=syncer.v1.Operation" json:"operation,omitempty"`
+	// The table to write, unqualified names refer to the public schema.
+	Table string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	// The columns identifying the row to update or delete.
+	Key *Row `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	// The columns to insert, or to set on update.
+	Data *Row `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// The row_version the row must still have for an update or delete to apply,
+	// empty to skip the check.
+	ExpectedVersion string `protobuf:"bytes,5,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Mutation) Reset() {
+	*x = Mutation{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mutation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mutation) ProtoMessage() {}
+
+func (x *Mutation) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mutation.ProtoReflect.Descriptor instead.
+func (*Mutation) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Mutation) GetOperation() Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return Operation_OPERATION_UNKNOWN
+}
+
+func (x *Mutation) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *Mutation) GetKey() *Row {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *Mutation) GetData() *Row {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Mutation) GetExpectedVersion() string {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return ""
+}
+
+type SubmitChangesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the transaction committed. It does only if every mutation applied.
+	Committed bool `protobuf:"varint,1,opt,name=committed,proto3" json:"committed,omitempty"`
+	// The outcome of each mutation, in the order they were submitted.
+	Results       []*MutationResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitChangesResponse) Reset() {
+	*x = SubmitChangesResponse{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitChangesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitChangesResponse) ProtoMessage() {}
+
+func (x *SubmitChangesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitChangesResponse.ProtoReflect.Descriptor instead.
+func (*SubmitChangesResponse) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SubmitChangesResponse) GetCommitted() bool {
+	if x != nil {
+		return x.Committed
+	}
+	return false
+}
+
+func (x *SubmitChangesResponse) GetResults() []*MutationResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type MutationResult struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status MutationStatus         `protobuf:"varint,1,opt,name=status,proto3,enum=syncer.v1.MutationStatus" json:"status,omitempty"`
+	// The version of the row after the mutation, once committed.
+	RowVersion string `protobuf:"bytes,2,opt,name=row_version,json=rowVersion,proto3" json:"row_version,omitempty"`
+	// Why the mutation did not apply. Columns the server masks are never
+	// written, the message of mutations setting them names them; mutations keyed
+	// by them fail.
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MutationResult) Reset() {
+	*x = MutationResult{}
+	mi := &file_syncer_v1_syncer_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MutationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MutationResult) ProtoMessage() {}
+
+func (x *MutationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_syncer_v1_syncer_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MutationResult.ProtoReflect.Descriptor instead.
+func (*MutationResult) Descriptor() ([]byte, []int) {
+	return file_syncer_v1_syncer_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *MutationResult) GetStatus() MutationStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MutationStatus_MUTATION_STATUS_UNKNOWN
+}
+
+func (x *MutationResult) GetRowVersion() string {
+	if x != nil {
+		return x.RowVersion
+	}
+	return ""
+}
+
+func (x *MutationResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_syncer_v1_syncer_proto protoreflect.FileDescriptor
+
+const file_syncer_v1_syncer_proto_rawDesc = "" +
+	"\n" +
+	"\x16syncer/v1/syncer.proto\x12\tsyncer.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa1\x04\n" +
+	"\x18StreamDataChangesRequest\x12\x16\n" +
+	"\x06tables\x18\x01 \x03(\tR\x06tables\x12'\n" +
+	"\x0fresume_position\x18\x02 \x01(\tR\x0eresumePosition\x12)\n" +
+	"\x10initial_snapshot\x18\x03 \x01(\bR\x0finitialSnapshot\x12T\n" +
+	"\vrow_filters\x18\x04 \x03(\v23.syncer.v1.StreamDataChangesRequest.RowFiltersEntryR\n" +
+	"rowFilters\x12J\n" +
+	"\acolumns\x18\x05 \x03(\v20.syncer.v1.StreamDataChangesRequest.ColumnsEntryR\acolumns\x12\x1b\n" +
+	"\tclient_id\x18\x06 \x01(\tR\bclientId\x12#\n" +
+	"\rsession_token\x18\a \x01(\tR\fsessionToken\x12#\n" +
+	"\rresume_cursor\x18\b \x01(\tR\fresumeCursor\x1a=\n" +
+	"\x0fRowFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aQ\n" +
+	"\fColumnsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x05value\x18\x02 \x01(\v2\x15.syncer.v1.ColumnListR\x05value:\x028\x01\"\x84\x01\n" +
+	"\x16SyncDataChangesRequest\x12;\n" +
+	"\x05start\x18\x01 \x01(\v2#.syncer.v1.StreamDataChangesRequestH\x00R\x05start\x12\"\n" +
+	"\x03ack\x18\x02 \x01(\v2\x0e.syncer.v1.AckH\x00R\x03ackB\t\n" +
+	"\amessage\"H\n" +
+	"\x03Ack\x12)\n" +
+	"\x10applied_position\x18\x01 \x01(\tR\x0fappliedPosition\x12\x16\n" +
+	"\x06window\x18\x02 \x01(\rR\x06window\"\xd1\x01\n" +
+	"\x0eConnectRequest\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x16\n" +
+	"\x06origin\x18\x02 \x01(\tR\x06origin\x12\"\n" +
+	"\fcapabilities\x18\x03 \x03(\tR\fcapabilities\x12)\n" +
+	"\x10protocol_version\x18\x04 \x01(\rR\x0fprotocolVersion\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\x12#\n" +
+	"\rsession_token\x18\x06 \x01(\tR\fsessionToken\"\x84\x02\n" +
+	"\x0fConnectResponse\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x1b\n" +
+	"\tserver_id\x18\x02 \x01(\tR\bserverId\x12\x18\n" +
+	"\abackend\x18\x03 \x01(\tR\abackend\x12)\n" +
+	"\x10published_tables\x18\x04 \x03(\tR\x0fpublishedTables\x12#\n" +
+	"\rsession_token\x18\x05 \x01(\tR\fsessionToken\x12)\n" +
+	"\x10protocol_version\x18\x06 \x01(\rR\x0fprotocolVersion\x12\"\n" +
+	"\fcapabilities\x18\a \x03(\tR\fcapabilities\"\x15\n" +
+	"\x13ListSessionsRequest\"F\n" +
+	"\x14ListSessionsResponse\x12.\n" +
+	"\bsessions\x18\x01 \x03(\v2\x12.syncer.v1.SessionR\bsessions\"\xb5\x05\n" +
+	"\aSession\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x16\n" +
+	"\x06origin\x18\x02 \x01(\tR\x06origin\x12\"\n" +
+	"\fcapabilities\x18\x03 \x03(\tR\fcapabilities\x12)\n" +
+	"\x10protocol_version\x18\x04 \x01(\rR\x0fprotocolVersion\x12=\n" +
+	"\fconnected_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vconnectedAt\x12\x1c\n" +
+	"\tstreaming\x18\x06 \x01(\bR\tstreaming\x12\x16\n" +
+	"\x06tables\x18\a \x03(\tR\x06tables\x12C\n" +
+	"\vrow_filters\x18\b \x03(\v2\".syncer.v1.Session.RowFiltersEntryR\n" +
+	"rowFilters\x129\n" +
+	"\acolumns\x18\t \x03(\v2\x1f.syncer.v1.Session.ColumnsEntryR\acolumns\x12\x16\n" +
+	"\x06cursor\x18\n" +
+	" \x01(\tR\x06cursor\x12\x1f\n" +
+	"\vqueue_depth\x18\v \x01(\x04R\n" +
+	"queueDepth\x12+\n" +
+	"\x03lag\x18\f \x01(\v2\x19.google.protobuf.DurationR\x03lag\x12!\n" +
+	"\facked_cursor\x18\r \x01(\tR\vackedCursor\x12\x16\n" +
+	"\x06window\x18\x0e \x01(\rR\x06window\x1a=\n" +
+	"\x0fRowFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aQ\n" +
+	"\fColumnsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x05value\x18\x02 \x01(\v2\x15.syncer.v1.ColumnListR\x05value:\x028\x01\"\"\n" +
+	"\n" +
+	"ColumnList\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"\xd2\x04\n" +
+	"\x0fDataChangeEvent\x122\n" +
+	"\toperation\x18\x01 \x01(\x0e2\x14.syncer.v1.OperationR\toperation\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\tR\x05table\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1a\n" +
+	"\bposition\x18\x06 \x01(\tR\bposition\x128\n" +
+	"\vtransaction\x18\a \x01(\v2\x16.syncer.v1.TransactionR\vtransaction\x12\"\n" +
+	"\x04data\x18\b \x01(\v2\x0e.syncer.v1.RowR\x04data\x12)\n" +
+	"\bold_data\x18\t \x01(\v2\x0e.syncer.v1.RowR\aoldData\x12\x1f\n" +
+	"\vkey_columns\x18\n" +
+	" \x03(\tR\n" +
+	"keyColumns\x124\n" +
+	"\brelation\x18\v \x01(\v2\x18.syncer.v1.RelationEventR\brelation\x12\x1f\n" +
+	"\vrelation_id\x18\f \x01(\rR\n" +
+	"relationId\x12A\n" +
+	"\rschema_change\x18\r \x01(\v2\x1c.syncer.v1.SchemaChangeEventR\fschemaChange\x12\x1f\n" +
+	"\vrow_version\x18\x0e \x01(\tR\n" +
+	"rowVersion\x12\x16\n" +
+	"\x06origin\x18\x0f \x01(\tR\x06origin\x12\x16\n" +
+	"\x06cursor\x18\x10 \x01(\tR\x06cursorJ\x04\b\x03\x10\x04J\x04\b\x04\x10\x05\"^\n" +
+	"\x11SchemaChangeEvent\x12\x1f\n" +
+	"\vcommand_tag\x18\x01 \x01(\tR\n" +
+	"commandTag\x12\x16\n" +
+	"\x06tables\x18\x02 \x03(\tR\x06tables\x12\x10\n" +
+	"\x03ddl\x18\x03 \x01(\tR\x03ddl\"\xc9\x01\n" +
+	"\rRelationEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x16\n" +
+	"\x06schema\x18\x02 \x01(\tR\x06schema\x12\x14\n" +
+	"\x05table\x18\x03 \x01(\tR\x05table\x123\n" +
+	"\acolumns\x18\x04 \x03(\v2\x19.syncer.v1.RelationColumnR\acolumns\x12E\n" +
+	"\x10replica_identity\x18\x05 \x01(\x0e2\x1a.syncer.v1.ReplicaIdentityR\x0freplicaIdentity\"\xaf\x01\n" +
+	"\x0eRelationColumn\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x19\n" +
+	"\btype_oid\x18\x02 \x01(\rR\atypeOid\x12#\n" +
+	"\rtype_modifier\x18\x03 \x01(\x05R\ftypeModifier\x12\x1b\n" +
+	"\ttype_name\x18\x04 \x01(\tR\btypeName\x12\x1a\n" +
+	"\bnullable\x18\x05 \x01(\bR\bnullable\x12\x10\n" +
+	"\x03key\x18\x06 \x01(\bR\x03key\"2\n" +
+	"\x03Row\x12+\n" +
+	"\acolumns\x18\x01 \x03(\v2\x11.syncer.v1.ColumnR\acolumns\"_\n" +
+	"\x06Column\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x19\n" +
+	"\btype_oid\x18\x02 \x01(\rR\atypeOid\x12&\n" +
+	"\x05value\x18\x03 \x01(\v2\x10.syncer.v1.ValueR\x05value\"\x82\x03\n" +
+	"\x05Value\x12;\n" +
+	"\n" +
+	"null_value\x18\x01 \x01(\x0e2\x1a.google.protobuf.NullValueH\x00R\tnullValue\x12\x1d\n" +
+	"\tint_value\x18\x02 \x01(\x03H\x00R\bintValue\x12!\n" +
+	"\vfloat_value\x18\x03 \x01(\x01H\x00R\n" +
+	"floatValue\x12\x1f\n" +
+	"\n" +
+	"text_value\x18\x04 \x01(\tH\x00R\ttextValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x05 \x01(\bH\x00R\tboolValue\x12!\n" +
+	"\vbytea_value\x18\x06 \x01(\fH\x00R\n" +
+	"byteaValue\x12E\n" +
+	"\x0ftimestamp_value\x18\a \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x0etimestampValue\x12%\n" +
+	"\rnumeric_value\x18\b \x01(\tH\x00R\fnumericValue\x12\x1f\n" +
+	"\n" +
+	"json_value\x18\t \x01(\tH\x00R\tjsonValueB\x06\n" +
+	"\x04kind\"\xbe\x01\n" +
+	"\vTransaction\x12\x10\n" +
+	"\x03xid\x18\x01 \x01(\rR\x03xid\x12\x1d\n" +
+	"\n" +
+	"commit_lsn\x18\x02 \x01(\tR\tcommitLsn\x12E\n" +
+	"\x10commit_timestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0fcommitTimestamp\x12\x1b\n" +
+	"\trow_count\x18\x04 \x01(\rR\browCount\x12\x1a\n" +
+	"\bsnapshot\x18\x05 \x01(\bR\bsnapshot\"\xdb\x01\n" +
+	"\x12PushChangesRequest\x12\x16\n" +
+	"\x06origin\x18\x01 \x01(\tR\x06origin\x122\n" +
+	"\x06change\x18\x02 \x01(\v2\x1a.syncer.v1.DataChangeEventR\x06change\x12!\n" +
+	"\fbase_version\x18\x03 \x01(\tR\vbaseVersion\x129\n" +
+	"\n" +
+	"changed_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tchangedAt\x12\x1b\n" +
+	"\tchange_id\x18\x05 \x01(\x04R\bchangeId\"H\n" +
+	"\x13PushChangesResponse\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.syncer.v1.ChangeResultR\aresults\"\x96\x01\n" +
+	"\fChangeResult\x12/\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x17.syncer.v1.ChangeStatusR\x06status\x12\x1a\n" +
+	"\bconflict\x18\x02 \x01(\bR\bconflict\x12\x1f\n" +
+	"\vrow_version\x18\x03 \x01(\tR\n" +
+	"rowVersion\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"I\n" +
+	"\x14SubmitChangesRequest\x121\n" +
+	"\tmutations\x18\x01 \x03(\v2\x13.syncer.v1.MutationR\tmutations\"\xc5\x01\n" +
+	"\bMutation\x122\n" +
+	"\toperation\x18\x01 \x01(\x0e2\x14.syncer.v1.OperationR\toperation\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\tR\x05table\x12 \n" +
+	"\x03key\x18\x03 \x01(\v2\x0e.syncer.v1.RowR\x03key\x12\"\n" +
+	"\x04data\x18\x04 \x01(\v2\x0e.syncer.v1.RowR\x04data\x12)\n" +
+	"\x10expected_version\x18\x05 \x01(\tR\x0fexpectedVersion\"j\n" +
+	"\x15SubmitChangesResponse\x12\x1c\n" +
+	"\tcommitted\x18\x01 \x01(\bR\tcommitted\x123\n" +
+	"\aresults\x18\x02 \x03(\v2\x19.syncer.v1.MutationResultR\aresults\"~\n" +
+	"\x0eMutationResult\x121\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x19.syncer.v1.MutationStatusR\x06status\x12\x1f\n" +
+	"\vrow_version\x18\x02 \x01(\tR\n" +
+	"rowVersion\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage*\xa2\x01\n" +
+	"\x0fReplicaIdentity\x12\x1c\n" +
+	"\x18REPLICA_IDENTITY_UNKNOWN\x10\x00\x12\x1c\n" +
+	"\x18REPLICA_IDENTITY_DEFAULT\x10\x01\x12\x1c\n" +
+	"\x18REPLICA_IDENTITY_NOTHING\x10\x02\x12\x19\n" +
+	"\x15REPLICA_IDENTITY_FULL\x10\x03\x12\x1a\n" +
+	"\x16REPLICA_IDENTITY_INDEX\x10\x04*\xc4\x01\n" +
+	"\tOperation\x12\x15\n" +
+	"\x11OPERATION_UNKNOWN\x10\x00\x12\x14\n" +
+	"\x10OPERATION_INSERT\x10\x01\x12\x14\n" +
+	"\x10OPERATION_UPDATE\x10\x02\x12\x14\n" +
+	"\x10OPERATION_DELETE\x10\x03\x12\x13\n" +
+	"\x0fOPERATION_BEGIN\x10\x04\x12\x14\n" +
+	"\x10OPERATION_COMMIT\x10\x05\x12\x16\n" +
+	"\x12OPERATION_RELATION\x10\x06\x12\x1b\n" +
+	"\x17OPERATION_SCHEMA_CHANGE\x10\a*\xae\x01\n" +
+	"\fChangeStatus\x12\x19\n" +
+	"\x15CHANGE_STATUS_UNKNOWN\x10\x00\x12\x19\n" +
+	"\x15CHANGE_STATUS_APPLIED\x10\x01\x12\x18\n" +
+	"\x14CHANGE_STATUS_MERGED\x10\x02\x12\x1a\n" +
+	"\x16CHANGE_STATUS_REJECTED\x10\x03\x12\x18\n" +
+	"\x14CHANGE_STATUS_PARKED\x10\x04\x12\x18\n" +
+	"\x14CHANGE_STATUS_FAILED\x10\x05*\xc8\x01\n" +
+	"\x0eMutationStatus\x12\x1b\n" +
+	"\x17MUTATION_STATUS_UNKNOWN\x10\x00\x12\x1b\n" +
+	"\x17MUTATION_STATUS_APPLIED\x10\x01\x12\x1d\n" +
+	"\x19MUTATION_STATUS_NOT_FOUND\x10\x02\x12$\n" +
+	" MUTATION_STATUS_VERSION_MISMATCH\x10\x03\x12\x1a\n" +
+	"\x16MUTATION_STATUS_FAILED\x10\x04\x12\x1b\n" +
+	"\x17MUTATION_STATUS_ABORTED\x10\x052\xab\x03\n" +
+	"\vChatService\x12B\n" +
+	"\aConnect\x12\x19.syncer.v1.ConnectRequest\x1a\x1a.syncer.v1.ConnectResponse\"\x00\x12X\n" +
+	"\x11StreamDataChanges\x12#.syncer.v1.StreamDataChangesRequest\x1a\x1a.syncer.v1.DataChangeEvent\"\x000\x01\x12V\n" +
+	"\x0fSyncDataChanges\x12!.syncer.v1.SyncDataChangesRequest\x1a\x1a.syncer.v1.DataChangeEvent\"\x00(\x010\x01\x12P\n" +
+	"\vPushChanges\x12\x1d.syncer.v1.PushChangesRequest\x1a\x1e.syncer.v1.PushChangesResponse\"\x00(\x01\x12T\n" +
+	"\rSubmitChanges\x12\x1f.syncer.v1.SubmitChangesRequest\x1a .syncer.v1.SubmitChangesResponse\"\x002a\n" +
+	"\fAdminService\x12Q\n" +
+	"\fListSessions\x12\x1e.syncer.v1.ListSessionsRequest\x1a\x1f.syncer.v1.ListSessionsResponse\"\x00B;Z9github.com/jckhoe-sandbox/syncer-playground/pkg/chat;chatb\x06proto3"
+
+var (
+	file_syncer_v1_syncer_proto_rawDescOnce sync.Once
+	file_syncer_v1_syncer_proto_rawDescData []byte
+)
+
+func file_syncer_v1_syncer_proto_rawDescGZIP() []byte {
+	file_syncer_v1_syncer_proto_rawDescOnce.Do(func() {
+		file_syncer_v1_syncer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_syncer_v1_syncer_proto_rawDesc), len(file_syncer_v1_syncer_proto_rawDesc)))
+	})
+	return file_syncer_v1_syncer_proto_rawDescData
+}
+
+var file_syncer_v1_syncer_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_syncer_v1_syncer_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_syncer_v1_syncer_proto_goTypes = []any{
+	(ReplicaIdentity)(0),             // 0: syncer.v1.ReplicaIdentity
+	(Operation)(0),                   // 1: syncer.v1.Operation
+	(ChangeStatus)(0),                // 2: syncer.v1.ChangeStatus
+	(MutationStatus)(0),              // 3: syncer.v1.MutationStatus
+	(*StreamDataChangesRequest)(nil), // 4: syncer.v1.StreamDataChangesRequest
+	(*SyncDataChangesRequest)(nil),   // 5: syncer.v1.SyncDataChangesRequest
+	(*Ack)(nil),                      // 6: syncer.v1.Ack
+	(*ConnectRequest)(nil),           // 7: syncer.v1.ConnectRequest
+	(*ConnectResponse)(nil),          // 8: syncer.v1.ConnectResponse
+	(*ListSessionsRequest)(nil),      // 9: syncer.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),     // 10: syncer.v1.ListSessionsResponse
+	(*Session)(nil),                  // 11: syncer.v1.Session
+	(*ColumnList)(nil),               // 12: syncer.v1.ColumnList
+	(*DataChangeEvent)(nil),          // 13: syncer.v1.DataChangeEvent
+	(*SchemaChangeEvent)(nil),        // 14: syncer.v1.SchemaChangeEvent
+	(*RelationEvent)(nil),            // 15: syncer.v1.RelationEvent
+	(*RelationColumn)(nil),           // 16: syncer.v1.RelationColumn
+	(*Row)(nil),                      // 17: syncer.v1.Row
+	(*Column)(nil),                   // 18: syncer.v1.Column
+	(*Value)(nil),                    // 19: syncer.v1.Value
+	(*Transaction)(nil),              // 20: syncer.v1.Transaction
+	(*PushChangesRequest)(nil),       // 21: syncer.v1.PushChangesRequest
+	(*PushChangesResponse)(nil),      // 22: syncer.v1.PushChangesResponse
+	(*ChangeResult)(nil),             // 23: syncer.v1.ChangeResult
+	(*SubmitChangesRequest)(nil),     // 24: syncer.v1.SubmitChangesRequest
+	(*Mutation)(nil),                 // 25: syncer.v1.Mutation
+	(*SubmitChangesResponse)(nil),    // 26: syncer.v1.SubmitChangesResponse
+	(*MutationResult)(nil),           // 27: syncer.v1.MutationResult
+	nil,                              // 28: syncer.v1.StreamDataChangesRequest.RowFiltersEntry
+	nil,                              // 29: syncer.v1.StreamDataChangesRequest.ColumnsEntry
+	nil,                              // 30: syncer.v1.Session.RowFiltersEntry
+	nil,                              // 31: syncer.v1.Session.ColumnsEntry
+	(*timestamppb.Timestamp)(nil),    // 32: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),      // 33: google.protobuf.Duration
+	(structpb.NullValue)(0),          // 34: google.protobuf.NullValue
+}
+var file_syncer_v1_syncer_proto_depIdxs = []int32{
+	28, // 0: syncer.v1.StreamDataChangesRequest.row_filters:type_name -> syncer.v1.StreamDataChangesRequest.RowFiltersEntry
+	29, // 1: syncer.v1.StreamDataChangesRequest.columns:type_name -> syncer.v1.StreamDataChangesRequest.ColumnsEntry
+	4,  // 2: syncer.v1.SyncDataChangesRequest.start:type_name -> syncer.v1.StreamDataChangesRequest
+	6,  // 3: syncer.v1.SyncDataChangesRequest.ack:type_name -> syncer.v1.Ack
+	11, // 4: syncer.v1.ListSessionsResponse.sessions:type_name -> syncer.v1.Session
+	32, // 5: syncer.v1.Session.connected_at:type_name -> google.protobuf.Timestamp
+	30, // 6: syncer.v1.Session.row_filters:type_name -> syncer.v1.Session.RowFiltersEntry
+	31, // 7: syncer.v1.Session.columns:type_name -> syncer.v1.Session.ColumnsEntry
+	33, // 8: syncer.v1.Session.lag:type_name -> google.protobuf.Duration
+	1,  // 9: syncer.v1.DataChangeEvent.operation:type_name -> syncer.v1.Operation
+	32, // 10: syncer.v1.DataChangeEvent.timestamp:type_name -> google.protobuf.Timestamp
+	20, // 11: syncer.v1.DataChangeEvent.transaction:type_name -> syncer.v1.Transaction
+	17, // 12: syncer.v1.DataChangeEvent.data:type_name -> syncer.v1.Row
+	17, // 13: syncer.v1.DataChangeEvent.old_data:type_name -> syncer.v1.Row
+	15, // 14: syncer.v1.DataChangeEvent.relation:type_name -> syncer.v1.RelationEvent
+	14, // 15: syncer.v1.DataChangeEvent.schema_change:type_name -> syncer.v1.SchemaChangeEvent
+	16, // 16: syncer.v1.RelationEvent.columns:type_name -> syncer.v1.RelationColumn
+	0,  // 17: syncer.v1.RelationEvent.replica_identity:type_name -> syncer.v1.ReplicaIdentity
+	18, // 18: syncer.v1.Row.columns:type_name -> syncer.v1.Column
+	19, // 19: syncer.v1.Column.value:type_name -> syncer.v1.Value
+	34, // 20: syncer.v1.Value.null_value:type_name -> google.protobuf.NullValue
+	32, // 21: syncer.v1.Value.timestamp_value:type_name -> google.protobuf.Timestamp
+	32, // 22: syncer.v1.Transaction.commit_timestamp:type_name -> google.protobuf.Timestamp
+	13, // 23: syncer.v1.PushChangesRequest.change:type_name -> syncer.v1.DataChangeEvent
+	32, // 24: syncer.v1.PushChangesRequest.changed_at:type_name -> google.protobuf.Timestamp
+	23, // 25: syncer.v1.PushChangesResponse.results:type_name -> syncer.v1.ChangeResult
+	2,  // 26: syncer.v1.ChangeResult.status:type_name -> syncer.v1.ChangeStatus
+	25, // 27: syncer.v1.SubmitChangesRequest.mutations:type_name -> syncer.v1.Mutation
+	1,  // 28: syncer.v1.Mutation.operation:type_name -> syncer.v1.Operation
+	17, // 29: syncer.v1.Mutation.key:type_name -> syncer.v1.Row
+	17, // 30: syncer.v1.Mutation.data:type_name -> syncer.v1.Row
+	27, // 31: syncer.v1.SubmitChangesResponse.results:type_name -> syncer.v1.MutationResult
+	3,  // 32: syncer.v1.MutationResult.status:type_name -> syncer.v1.MutationStatus
+	12, // 33: syncer.v1.StreamDataChangesRequest.ColumnsEntry.value:type_name -> syncer.v1.ColumnList
+	12, // 34: syncer.v1.Session.ColumnsEntry.value:type_name -> syncer.v1.ColumnList
+	7,  // 35: syncer.v1.ChatService.Connect:input_type -> syncer.v1.ConnectRequest
+	4,  // 36: syncer.v1.ChatService.StreamDataChanges:input_type -> syncer.v1.StreamDataChangesRequest
+	5,  // 37: syncer.v1.ChatService.SyncDataChanges:input_type -> syncer.v1.SyncDataChangesRequest
+	21, // 38: syncer.v1.ChatService.PushChanges:input_type -> syncer.v1.PushChangesRequest
+	24, // 39: syncer.v1.ChatService.SubmitChanges:input_type -> syncer.v1.SubmitChangesRequest
+	9,  // 40: syncer.v1.AdminService.ListSessions:input_type -> syncer.v1.ListSessionsRequest
+	8,  // 41: syncer.v1.ChatService.Connect:output_type -> syncer.v1.ConnectResponse
+	13, // 42: syncer.v1.ChatService.StreamDataChanges:output_type -> syncer.v1.DataChangeEvent
+	13, // 43: syncer.v1.ChatService.SyncDataChanges:output_type -> syncer.v1.DataChangeEvent
+	22, // 44: syncer.v1.ChatService.PushChanges:output_type -> syncer.v1.PushChangesResponse
+	26, // 45: syncer.v1.ChatService.SubmitChanges:output_type -> syncer.v1.SubmitChangesResponse
+	10, // 46: syncer.v1.AdminService.ListSessions:output_type -> syncer.v1.ListSessionsResponse
+	41, // [41:47] is the sub-list for method output_type
+	35, // [35:41] is the sub-list for method input_type
+	35, // [35:35] is the sub-list for extension type_name
+	35, // [35:35] is the sub-list for extension extendee
+	0,  // [0:35] is the sub-list for field type_name
+}
+
+func init() { file_syncer_v1_syncer_proto_init() }
+func file_syncer_v1_syncer_proto_init() {
+	if File_syncer_v1_syncer_proto != nil {
+		return
+	}
+	file_syncer_v1_syncer_proto_msgTypes[1].OneofWrappers = []any{
+		(*SyncDataChangesRequest_Start)(nil),
+		(*SyncDataChangesRequest_Ack)(nil),
+	}
+	file_syncer_v1_syncer_proto_msgTypes[15].OneofWrappers = []any{
+		(*Value_NullValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_FloatValue)(nil),
+		(*Value_TextValue)(nil),
+		(*Value_BoolValue)(nil),
+		(*Value_ByteaValue)(nil),
+		(*Value_TimestampValue)(nil),
+		(*Value_NumericValue)(nil),
+		(*Value_JsonValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_syncer_v1_syncer_proto_rawDesc), len(file_syncer_v1_syncer_proto_rawDesc)),
+			NumEnums:      4,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_syncer_v1_syncer_proto_goTypes,
+		DependencyIndexes: file_syncer_v1_syncer_proto_depIdxs,
+		EnumInfos:         file_syncer_v1_syncer_proto_enumTypes,
+		MessageInfos:      file_syncer_v1_syncer_proto_msgTypes,
+	}.Build()
+	File_syncer_v1_syncer_proto = out.File
+	file_syncer_v1_syncer_proto_goTypes = nil
+	file_syncer_v1_syncer_proto_depIdxs = nil
+}
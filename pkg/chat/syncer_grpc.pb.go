@@ -0,0 +1,396 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: syncer/v1/syncer.proto
+
+package chat
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ChatService_Connect_FullMethodName           = "/syncer.v1.ChatService/Connect"
+	ChatService_StreamDataChanges_FullMethodName = "/syncer.v1.ChatService/StreamDataChanges"
+	ChatService_SyncDataChanges_FullMethodName   = "/syncer.v1.ChatService/SyncDataChanges"
+	ChatService_PushChanges_FullMethodName       = "/syncer.v1.ChatService/PushChanges"
+	ChatService_SubmitChanges_FullMethodName     = "/syncer.v1.ChatService/SubmitChanges"
+)
+
+// ChatServiceClient is the client API for ChatService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The chat service definition.
+type ChatServiceClient interface {
+	// Introduce a client before it streams or pushes changes. The server assigns
+	// an id to clients that do not bring their own.
+	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error)
+	// Stream data changes from the server to the client.
+	StreamDataChanges(ctx context.Context, in *StreamDataChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChangeEvent], error)
+	// Stream data changes under flow control. The client starts the stream, then
+	// acknowledges the changes it applied and grants credit for more.
+	SyncDataChanges(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncDataChangesRequest, DataChangeEvent], error)
+	// Push changes made on a replica back to the server, which applies them to
+	// the source and resolves conflicts with changes the replica has not seen.
+	PushChanges(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PushChangesRequest, PushChangesResponse], error)
+	// Apply a batch of mutations to published tables in one transaction. The
+	// changes reach subscribers through the stream like any other write.
+	SubmitChanges(ctx context.Context, in *SubmitChangesRequest, opts ...grpc.CallOption) (*SubmitChangesResponse, error)
+}
+
+type chatServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChatServiceClient(cc grpc.ClientConnInterface) ChatServiceClient {
+	return &chatServiceClient{cc}
+}
+
+func (c *chatServiceClient) Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectResponse)
+	err := c.cc.Invoke(ctx, ChatService_Connect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) StreamDataChanges(ctx context.Context, in *StreamDataChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DataChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChatService_ServiceDesc.Streams[0], ChatService_StreamDataChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamDataChangesRequest, DataChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_StreamDataChangesClient = grpc.ServerStreamingClient[DataChangeEvent]
+
+func (c *chatServiceClient) SyncDataChanges(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[SyncDataChangesRequest, DataChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChatService_ServiceDesc.Streams[1], ChatService_SyncDataChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SyncDataChangesRequest, DataChangeEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_SyncDataChangesClient = grpc.BidiStreamingClient[SyncDataChangesRequest, DataChangeEvent]
+
+func (c *chatServiceClient) PushChanges(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PushChangesRequest, PushChangesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChatService_ServiceDesc.Streams[2], ChatService_PushChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PushChangesRequest, PushChangesResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_PushChangesClient = grpc.ClientStreamingClient[PushChangesRequest, PushChangesResponse]
+
+func (c *chatServiceClient) SubmitChanges(ctx context.Context, in *SubmitChangesRequest, opts ...grpc.CallOption) (*SubmitChangesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitChangesResponse)
+	err := c.cc.Invoke(ctx, ChatService_SubmitChanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChatServiceServer is the server API for ChatService service.
+// All implementations must embed UnimplementedChatServiceServer
+// for forward compatibility.
+//
+// The chat service definition.
+type ChatServiceServer interface {
+	// Introduce a client before it streams or pushes changes. The server assigns
+	// an id to clients that do not bring their own.
+	Connect(context.Context, *ConnectRequest) (*ConnectResponse, error)
+	// Stream data changes from the server to the client.
+	StreamDataChanges(*StreamDataChangesRequest, grpc.ServerStreamingServer[DataChangeEvent]) error
+	// Stream data changes under flow control. The client starts the stream, then
+	// acknowledges the changes it applied and grants credit for more.
+	SyncDataChanges(grpc.BidiStreamingServer[SyncDataChangesRequest, DataChangeEvent]) error
+	// Push changes made on a replica back to the server, which applies them to
+	// the source and resolves conflicts with changes the replica has not seen.
+	PushChanges(grpc.ClientStreamingServer[PushChangesRequest, PushChangesResponse]) error
+	// Apply a batch of mutations to published tables in one transaction. The
+	// changes reach subscribers through the stream like any other write.
+	SubmitChanges(context.Context, *SubmitChangesRequest) (*SubmitChangesResponse, error)
+	mustEmbedUnimplementedChatServiceServer()
+}
+
+// UnimplementedChatServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChatServiceServer struct{}
+
+func (UnimplementedChatServiceServer) Connect(context.Context, *ConnectRequest) (*ConnectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedChatServiceServer) StreamDataChanges(*StreamDataChangesRequest, grpc.ServerStreamingServer[DataChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDataChanges not implemented")
+}
+func (UnimplementedChatServiceServer) SyncDataChanges(grpc.BidiStreamingServer[SyncDataChangesRequest, DataChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method SyncDataChanges not implemented")
+}
+func (UnimplementedChatServiceServer) PushChanges(grpc.ClientStreamingServer[PushChangesRequest, PushChangesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method PushChanges not implemented")
+}
+func (UnimplementedChatServiceServer) SubmitChanges(context.Context, *SubmitChangesRequest) (*SubmitChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitChanges not implemented")
+}
+func (UnimplementedChatServiceServer) mustEmbedUnimplementedChatServiceServer() {}
+func (UnimplementedChatServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeChatServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChatServiceServer will
+// result in compilation errors.
+type UnsafeChatServiceServer interface {
+	mustEmbedUnimplementedChatServiceServer()
+}
+
+func RegisterChatServiceServer(s grpc.ServiceRegistrar, srv ChatServiceServer) {
+	// If the following call pancis, it indicates UnimplementedChatServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ChatService_ServiceDesc, srv)
+}
+
+func _ChatService_Connect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Connect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatService_Connect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Connect(ctx, req.(*ConnectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_StreamDataChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDataChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServiceServer).StreamDataChanges(m, &grpc.GenericServerStream[StreamDataChangesRequest, DataChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_StreamDataChangesServer = grpc.ServerStreamingServer[DataChangeEvent]
+
+func _ChatService_SyncDataChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServiceServer).SyncDataChanges(&grpc.GenericServerStream[SyncDataChangesRequest, DataChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_SyncDataChangesServer = grpc.BidiStreamingServer[SyncDataChangesRequest, DataChangeEvent]
+
+func _ChatService_PushChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServiceServer).PushChanges(&grpc.GenericServerStream[PushChangesRequest, PushChangesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChatService_PushChangesServer = grpc.ClientStreamingServer[PushChangesRequest, PushChangesResponse]
+
+func _ChatService_SubmitChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).SubmitChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatService_SubmitChanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).SubmitChanges(ctx, req.(*SubmitChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChatService_ServiceDesc is the grpc.ServiceDesc for ChatService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChatService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "syncer.v1.ChatService",
+	HandlerType: (*ChatServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Connect",
+			Handler:    _ChatService_Connect_Handler,
+		},
+		{
+			MethodName: "SubmitChanges",
+			Handler:    _ChatService_SubmitChanges_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDataChanges",
+			Handler:       _ChatService_StreamDataChanges_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SyncDataChanges",
+			Handler:       _ChatService_SyncDataChanges_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "PushChanges",
+			Handler:       _ChatService_PushChanges_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "syncer/v1/syncer.proto",
+}
+
+const (
+	AdminService_ListSessions_FullMethodName = "/syncer.v1.AdminService/ListSessions"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Administration of a server instance.
+type AdminServiceClient interface {
+	// List the sessions of the clients connected to the server, with how far
+	// behind each of them is.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// Administration of a server instance.
+type AdminServiceServer interface {
+	// List the sessions of the clients connected to the server, with how far
+	// behind each of them is.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "syncer.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _AdminService_ListSessions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "syncer/v1/syncer.proto",
+}
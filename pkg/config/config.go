@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -23,12 +25,107 @@ type Config struct {
 		DB       int
 	}
 	Server struct {
+		// Names this instance to clients, the host name by default
+		ID   string
 		Port int
+		// Address the administration service listens on, apart from clients. Empty
+		// disables it.
+		AdminAddress string
+		// Number of recent events kept in memory for resuming clients
+		HistorySize int
+		// How long the session of a client that stopped streaming is kept
+		SessionIdleTimeout time.Duration
+	}
+	Bus struct {
+		// Carries events from the replicator to the server: memory, redis or
+		// redis-streams
+		Backend string
+		// Key of the Redis stream of the redis-streams bus
+		Stream string
+		// Entries the stream is trimmed to, approximately
+		StreamMaxLen int64
+	}
+	Fanout struct {
+		// Events queued for each subscriber before its policy applies
+		QueueSize int
+		// What to do with a subscriber whose queue is full: block, disconnect or spill
+		Policy string
+		// How long block waits for room before disconnecting the subscriber
+		BlockTimeout time.Duration
+		// Directory spill files are written to, the system temporary directory by default
+		SpillDir string
+		// Bytes a subscriber may spill before it is disconnected, 0 for no limit
+		SpillLimit int64
+		// How long a flow controlled subscriber may withhold credit before it is
+		// disconnected
+		AckTimeout time.Duration
 	}
 	Replication struct {
 		Slot        string
 		Publication string
+		// Capture DDL statements with an event trigger and stream them. Creating
+		// the trigger takes a superuser, so it is opt-in.
+		CaptureDDL bool
+		// Replication origins of syncer peers, whose changes are not streamed
+		PeerOrigins []string
+	}
+	Masking struct {
+		// Columns left out of every row image, as table.column or schema.table.column
+		Redact []string
+		// Columns replaced with the HMAC-SHA256 of their value under HashKey
+		Hash    []string
+		HashKey string
+	}
+	Conflicts struct {
+		// How changes pushed by replicas are reconciled with the source:
+		// last-writer-wins, origin-priority, column-merge or park
+		Resolver string
+		// Origins by decreasing priority, for origin-priority
+		OriginPriority []string
+		// Origin of rows written directly on the source
+		SourceOrigin string
+	}
+	Client struct {
+		// Apply each upstream transaction atomically instead of row by row
+		TransactionalApply bool
+		// Execute DDL statements captured on the source
+		ApplySchemaChanges bool
+		// Capture local changes and push them upstream
+		Bidirectional bool
+		// Identifies this client as the origin of the changes it pushes
+		Origin string
+		// Servers to stream changes from
+		Upstreams []Upstream
+		// Name of the upstream local changes are pushed to, the first by default
+		PushUpstream string
+		// Events the client lets servers send ahead of what it applied, 0 to stream
+		// without flow control
+		AckWindow int
+		// How often applied positions are acknowledged to servers
+		AckInterval time.Duration
+	}
+}
+
+// Upstream is a server the client streams changes from, configured by
+// SYNCER_UPSTREAM_<NAME>_* variables
+type Upstream struct {
+	// Names the server in logs, applied positions and replication origins
+	Name    string
+	Address string
+	TLS     struct {
+		Enabled bool
+		// CA certificates verifying the server, the system roots by default
+		CAFile string
+		// Client certificate and key for mutual TLS
+		CertFile           string
+		KeyFile            string
+		ServerName         string
+		InsecureSkipVerify bool
 	}
+	// Tables to stream, names or glob patterns, every published table by default
+	Tables []string
+	// Database the changes are applied to, the PostgreSQL database by default
+	TargetDSN string
 }
 
 func (c *Config) GetPostgresDSN() string {
@@ -60,9 +157,40 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("SYNCER_REDIS_PORT", 6379)
 	viper.SetDefault("SYNCER_REDIS_PASSWORD", "")
 	viper.SetDefault("SYNCER_REDIS_DB", 0)
+	viper.SetDefault("SYNCER_SERVER_ID", "")
 	viper.SetDefault("SYNCER_SERVER_PORT", 50051)
+	viper.SetDefault("SYNCER_SERVER_ADMIN_ADDRESS", "127.0.0.1:50061")
+	viper.SetDefault("SYNCER_SERVER_HISTORY_SIZE", 10000)
+	viper.SetDefault("SYNCER_SERVER_SESSION_IDLE_TIMEOUT", "5m")
+	viper.SetDefault("SYNCER_BUS_BACKEND", "memory")
+	viper.SetDefault("SYNCER_BUS_STREAM", "syncer:data_changes")
+	viper.SetDefault("SYNCER_BUS_STREAM_MAX_LEN", 100000)
+	viper.SetDefault("SYNCER_FANOUT_QUEUE_SIZE", 100)
+	viper.SetDefault("SYNCER_FANOUT_POLICY", "disconnect")
+	viper.SetDefault("SYNCER_FANOUT_BLOCK_TIMEOUT", "5s")
+	viper.SetDefault("SYNCER_FANOUT_SPILL_DIR", "")
+	viper.SetDefault("SYNCER_FANOUT_SPILL_LIMIT", 1<<30)
+	viper.SetDefault("SYNCER_FANOUT_ACK_TIMEOUT", "30s")
 	viper.SetDefault("SYNCER_REPLICATION_SLOT", "syncer_slot")
 	viper.SetDefault("SYNCER_REPLICATION_PUBLICATION", "syncer_pub")
+	viper.SetDefault("SYNCER_REPLICATION_CAPTURE_DDL", false)
+	viper.SetDefault("SYNCER_REPLICATION_PEER_ORIGINS", "syncer_*")
+	viper.SetDefault("SYNCER_MASKING_REDACT", "")
+	viper.SetDefault("SYNCER_MASKING_HASH", "")
+	viper.SetDefault("SYNCER_MASKING_HASH_KEY", "")
+	viper.SetDefault("SYNCER_CONFLICTS_RESOLVER", "last-writer-wins")
+	viper.SetDefault("SYNCER_CONFLICTS_ORIGIN_PRIORITY", "")
+	viper.SetDefault("SYNCER_CONFLICTS_SOURCE_ORIGIN", "source")
+	viper.SetDefault("SYNCER_CLIENT_TRANSACTIONAL_APPLY", true)
+	viper.SetDefault("SYNCER_CLIENT_APPLY_SCHEMA_CHANGES", false)
+	viper.SetDefault("SYNCER_CLIENT_BIDIRECTIONAL", false)
+	viper.SetDefault("SYNCER_CLIENT_ORIGIN", "")
+	viper.SetDefault("SYNCER_CLIENT_UPSTREAMS", "postgres-only,postgres-redis")
+	viper.SetDefault("SYNCER_CLIENT_PUSH_UPSTREAM", "")
+	viper.SetDefault("SYNCER_CLIENT_ACK_WINDOW", 1000)
+	viper.SetDefault("SYNCER_CLIENT_ACK_INTERVAL", "1s")
+	viper.SetDefault("SYNCER_UPSTREAM_POSTGRES_ONLY_ADDRESS", "localhost:50051")
+	viper.SetDefault("SYNCER_UPSTREAM_POSTGRES_REDIS_ADDRESS", "localhost:50052")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -87,22 +215,121 @@ func LoadConfig() (*Config, error) {
 	config.Redis.DB = viper.GetInt("SYNCER_REDIS_DB")
 
 	// Load server configuration
+	config.Server.ID = viper.GetString("SYNCER_SERVER_ID")
 	config.Server.Port = viper.GetInt("SYNCER_SERVER_PORT")
+	config.Server.AdminAddress = viper.GetString("SYNCER_SERVER_ADMIN_ADDRESS")
+	config.Server.HistorySize = viper.GetInt("SYNCER_SERVER_HISTORY_SIZE")
+	config.Server.SessionIdleTimeout = viper.GetDuration("SYNCER_SERVER_SESSION_IDLE_TIMEOUT")
+
+	// Load event bus configuration
+	config.Bus.Backend = viper.GetString("SYNCER_BUS_BACKEND")
+	config.Bus.Stream = viper.GetString("SYNCER_BUS_STREAM")
+	config.Bus.StreamMaxLen = viper.GetInt64("SYNCER_BUS_STREAM_MAX_LEN")
+	if config.Server.ID == "" {
+		// The consumer group of a server outlives its container, so its name must too
+		if config.Bus.Backend == "redis-streams" {
+			return nil, fmt.Errorf("SYNCER_SERVER_ID must be set on the redis-streams bus, it names the consumer group of the server")
+		}
+		config.Server.ID = hostname("server")
+	}
+
+	// Load fan-out configuration
+	config.Fanout.QueueSize = viper.GetInt("SYNCER_FANOUT_QUEUE_SIZE")
+	config.Fanout.Policy = viper.GetString("SYNCER_FANOUT_POLICY")
+	config.Fanout.BlockTimeout = viper.GetDuration("SYNCER_FANOUT_BLOCK_TIMEOUT")
+	config.Fanout.SpillDir = viper.GetString("SYNCER_FANOUT_SPILL_DIR")
+	config.Fanout.SpillLimit = viper.GetInt64("SYNCER_FANOUT_SPILL_LIMIT")
+	config.Fanout.AckTimeout = viper.GetDuration("SYNCER_FANOUT_ACK_TIMEOUT")
 
 	// Load replication configuration
 	config.Replication.Slot = viper.GetString("SYNCER_REPLICATION_SLOT")
 	config.Replication.Publication = viper.GetString("SYNCER_REPLICATION_PUBLICATION")
+	config.Replication.CaptureDDL = viper.GetBool("SYNCER_REPLICATION_CAPTURE_DDL")
+	config.Replication.PeerOrigins = splitList(viper.GetString("SYNCER_REPLICATION_PEER_ORIGINS"))
+
+	// Load masking configuration
+	config.Masking.Redact = splitList(viper.GetString("SYNCER_MASKING_REDACT"))
+	config.Masking.Hash = splitList(viper.GetString("SYNCER_MASKING_HASH"))
+	config.Masking.HashKey = viper.GetString("SYNCER_MASKING_HASH_KEY")
+
+	// Load conflict resolution configuration
+	config.Conflicts.Resolver = viper.GetString("SYNCER_CONFLICTS_RESOLVER")
+	config.Conflicts.OriginPriority = splitList(viper.GetString("SYNCER_CONFLICTS_ORIGIN_PRIORITY"))
+	config.Conflicts.SourceOrigin = viper.GetString("SYNCER_CONFLICTS_SOURCE_ORIGIN")
+
+	// Load client configuration
+	config.Client.TransactionalApply = viper.GetBool("SYNCER_CLIENT_TRANSACTIONAL_APPLY")
+	config.Client.ApplySchemaChanges = viper.GetBool("SYNCER_CLIENT_APPLY_SCHEMA_CHANGES")
+	config.Client.Bidirectional = viper.GetBool("SYNCER_CLIENT_BIDIRECTIONAL")
+	config.Client.Origin = viper.GetString("SYNCER_CLIENT_ORIGIN")
+	if config.Client.Origin == "" {
+		config.Client.Origin = hostname("client")
+	}
+	for _, name := range splitList(viper.GetString("SYNCER_CLIENT_UPSTREAMS")) {
+		upstream, err := loadUpstream(name, config.GetPostgresDSN())
+		if err != nil {
+			return nil, err
+		}
+		config.Client.Upstreams = append(config.Client.Upstreams, upstream)
+	}
+	config.Client.PushUpstream = viper.GetString("SYNCER_CLIENT_PUSH_UPSTREAM")
+	if config.Client.PushUpstream == "" && len(config.Client.Upstreams) > 0 {
+		config.Client.PushUpstream = config.Client.Upstreams[0].Name
+	}
+	config.Client.AckWindow = viper.GetInt("SYNCER_CLIENT_ACK_WINDOW")
+	config.Client.AckInterval = viper.GetDuration("SYNCER_CLIENT_ACK_INTERVAL")
 
 	return config, nil
 }
 
-// GetDSN returns the PostgreSQL connection string
-func (c *PostgresConfig) GetDSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
+// loadUpstream loads the configuration of the upstream name. Its variables are named
+// after it in upper case, with anything but letters and digits replaced by
+// underscores: SYNCER_UPSTREAM_EU_WEST_ADDRESS for "eu-west".
+func loadUpstream(name, defaultDSN string) (Upstream, error) {
+	prefix := "SYNCER_UPSTREAM_" + strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name)) + "_"
+
+	upstream := Upstream{
+		Name:      name,
+		Address:   viper.GetString(prefix + "ADDRESS"),
+		Tables:    splitList(viper.GetString(prefix + "TABLES")),
+		TargetDSN: viper.GetString(prefix + "TARGET_DSN"),
+	}
+	if upstream.Address == "" {
+		return Upstream{}, fmt.Errorf("no address configured for upstream %s, set %sADDRESS", name, prefix)
+	}
+	if upstream.TargetDSN == "" {
+		upstream.TargetDSN = defaultDSN
+	}
+	upstream.TLS.Enabled = viper.GetBool(prefix + "TLS")
+	upstream.TLS.CAFile = viper.GetString(prefix + "TLS_CA_FILE")
+	upstream.TLS.CertFile = viper.GetString(prefix + "TLS_CERT_FILE")
+	upstream.TLS.KeyFile = viper.GetString(prefix + "TLS_KEY_FILE")
+	upstream.TLS.ServerName = viper.GetString(prefix + "TLS_SERVER_NAME")
+	upstream.TLS.InsecureSkipVerify = viper.GetBool(prefix + "TLS_INSECURE_SKIP_VERIFY")
+	return upstream, nil
+}
+
+// hostname returns the name of the host, or fallback if it is unknown
+func hostname(fallback string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		return fallback
+	}
+	return host
 }
 
-// GetAddr returns the Redis connection address
-func (c *RedisConfig) GetAddr() string {
-	return fmt.Sprintf("%s:%d", c.Host, c.Port)
-} 
\ No newline at end of file
+// splitList splits a comma-separated setting, ignoring blank entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
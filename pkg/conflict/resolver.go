@@ -0,0 +1,162 @@
+package conflict
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// Action is what a Resolver decides to do with a conflicting change
+type Action int
+
+const (
+	// ApplyIncoming applies the change as pushed
+	ApplyIncoming Action = iota
+	// KeepCurrent drops the change and leaves the row as it is
+	KeepCurrent
+	// ApplyMerged writes the row of the resolution
+	ApplyMerged
+	// Park records the conflict for manual review and leaves the row as it is
+	Park
+)
+
+// Conflict is a change pushed by a replica for a row that moved on at the source
+// since the replica last received it. Rows map column names to their values in
+// the text form of to_jsonb, nil for NULL.
+type Conflict struct {
+	Table     string
+	Key       map[string]interface{}
+	Operation chat.Operation
+
+	// The replica the change was made on, and when
+	Origin    string
+	ChangedAt time.Time
+
+	// The row on the replica before and after the change. Base is nil for
+	// inserts, Incoming for deletes.
+	Base     map[string]interface{}
+	Incoming map[string]interface{}
+
+	// The row on the source, nil if it is gone, with the origin that last wrote
+	// it and when. CurrentTime is zero unless track_commit_timestamp is on.
+	Current       map[string]interface{}
+	CurrentOrigin string
+	CurrentTime   time.Time
+}
+
+// Resolution is the outcome of a conflict
+type Resolution struct {
+	Action Action
+	// The row to write for ApplyMerged
+	Row map[string]interface{}
+}
+
+// Resolver decides how conflicting changes are reconciled with the source
+type Resolver interface {
+	Resolve(ctx context.Context, c *Conflict) (Resolution, error)
+}
+
+// NewResolver returns the resolver configured by name
+func NewResolver(name string, originPriority []string) (Resolver, error) {
+	switch name {
+	case "last-writer-wins", "":
+		return LastWriterWins{}, nil
+	case "origin-priority":
+		return &OriginPriority{Origins: originPriority, Fallback: LastWriterWins{}}, nil
+	case "column-merge":
+		return &ColumnMerge{Fallback: Parker{}}, nil
+	case "park":
+		return Parker{}, nil
+	}
+	return nil, fmt.Errorf("unknown conflict resolver %q", name)
+}
+
+// UsesCommitTime reports whether r, or a resolver it falls back to, orders writes
+// by commit time, which needs track_commit_timestamp on the source
+func UsesCommitTime(r Resolver) bool {
+	switch r := r.(type) {
+	case LastWriterWins:
+		return true
+	case *OriginPriority:
+		return UsesCommitTime(r.Fallback)
+	case *ColumnMerge:
+		return UsesCommitTime(r.Fallback)
+	}
+	return false
+}
+
+// LastWriterWins keeps whichever write was committed last. Changes win over rows
+// whose commit time is unknown, written before track_commit_timestamp was on.
+type LastWriterWins struct{}
+
+func (LastWriterWins) Resolve(ctx context.Context, c *Conflict) (Resolution, error) {
+	if c.CurrentTime.IsZero() || c.ChangedAt.After(c.CurrentTime) {
+		return Resolution{Action: ApplyIncoming}, nil
+	}
+	return Resolution{Action: KeepCurrent}, nil
+}
+
+// OriginPriority keeps the write of the origin listed first. Unlisted origins rank
+// below listed ones, and ties go to Fallback.
+type OriginPriority struct {
+	Origins  []string
+	Fallback Resolver
+}
+
+func (r *OriginPriority) Resolve(ctx context.Context, c *Conflict) (Resolution, error) {
+	incoming, current := r.rank(c.Origin), r.rank(c.CurrentOrigin)
+	switch {
+	case incoming < current:
+		return Resolution{Action: ApplyIncoming}, nil
+	case incoming > current:
+		return Resolution{Action: KeepCurrent}, nil
+	}
+	return r.Fallback.Resolve(ctx, c)
+}
+
+func (r *OriginPriority) rank(origin string) int {
+	for i, o := range r.Origins {
+		if o == origin {
+			return i
+		}
+	}
+	return len(r.Origins)
+}
+
+// ColumnMerge applies the columns an update changed on top of the row on the
+// source, as long as the source left those columns alone. Anything else, including
+// a column changed on both sides, goes to Fallback.
+type ColumnMerge struct {
+	Fallback Resolver
+}
+
+func (r *ColumnMerge) Resolve(ctx context.Context, c *Conflict) (Resolution, error) {
+	if c.Operation != chat.Operation_OPERATION_UPDATE || c.Base == nil || c.Current == nil {
+		return r.Fallback.Resolve(ctx, c)
+	}
+
+	merged := make(map[string]interface{}, len(c.Current))
+	for name, value := range c.Current {
+		merged[name] = value
+	}
+	for name, value := range c.Incoming {
+		base, ok := c.Base[name]
+		if !ok || value == base {
+			continue
+		}
+		if current := c.Current[name]; current != base && current != value {
+			return r.Fallback.Resolve(ctx, c)
+		}
+		merged[name] = value
+	}
+	return Resolution{Action: ApplyMerged, Row: merged}, nil
+}
+
+// Parker leaves every conflict for manual review
+type Parker struct{}
+
+func (Parker) Resolve(ctx context.Context, c *Conflict) (Resolution, error) {
+	return Resolution{Action: Park}, nil
+}
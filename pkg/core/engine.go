@@ -0,0 +1,481 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/events"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/fanout"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/replication"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/session"
+)
+
+// ErrInvalidRequest is returned for requests that ask for something the engine
+// cannot serve, such as an unknown table
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Engine serves the change stream of a source database independently of the
+// transport clients reach it over. The replicator publishes changes to the bus,
+// and every event coming back from the bus is kept in the history and fanned out
+// to the subscribed clients.
+type Engine struct {
+	cfg        *config.Config
+	db         *gorm.DB
+	replicator *replication.PostgresReplicator
+	bus        events.Bus
+	pushes     *replication.PushApplier
+	history    *replication.History
+	hub        *fanout.Hub
+	sessions   *session.Registry
+	// Receives the error replication failed with
+	failed chan error
+
+	// Guards the history together with subscribing to the hub, so that clients
+	// catching up miss no events
+	mu sync.Mutex
+	// Commit time of the newest event, to tell how far behind clients are
+	head time.Time
+
+	// Serializes updates of the position, and bus cursor, retained for the slowest
+	// client
+	retaining      sync.Mutex
+	retained       replication.Position
+	retainedCursor string
+}
+
+// NewEngine creates an engine for the replicator, whose replication must be set up
+func NewEngine(cfg *config.Config, db *gorm.DB, replicator *replication.PostgresReplicator, bus events.Bus, pushes *replication.PushApplier) (*Engine, error) {
+	policy, err := fanout.ParsePolicy(cfg.Fanout.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep recent events for clients that reconnect
+	floor, err := replicator.ResumeFloor(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resume floor: %w", err)
+	}
+
+	return &Engine{
+		cfg:        cfg,
+		db:         db,
+		replicator: replicator,
+		bus:        bus,
+		pushes:     pushes,
+		history:    replication.NewHistory(cfg.Server.HistorySize, floor),
+		hub: fanout.NewHub(fanout.Options{
+			QueueSize:    cfg.Fanout.QueueSize,
+			Policy:       policy,
+			BlockTimeout: cfg.Fanout.BlockTimeout,
+			SpillDir:     cfg.Fanout.SpillDir,
+			SpillLimit:   cfg.Fanout.SpillLimit,
+		}),
+		sessions: session.NewRegistry(cfg.Server.SessionIdleTimeout),
+		failed:   make(chan error, 1),
+	}, nil
+}
+
+// Start streams changes from the replication slot through the bus until ctx is
+// done. Of the servers sharing the slot, only the one owning it streams from it;
+// the others serve what it publishes on the bus, and take over once it is gone.
+func (e *Engine) Start(ctx context.Context) error {
+	// Subscribe first, so nothing the replicator publishes goes past the engine
+	busEvents, err := e.bus.Subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to the %s bus: %w", e.cfg.Bus.Backend, err)
+	}
+	go e.broadcast(ctx, busEvents)
+	go e.replicate(ctx)
+	return nil
+}
+
+// Failed returns a channel receiving the error replication failed with. Once it
+// does, no more changes reach the clients and the engine must be stopped: starting
+// again resumes from the last checkpoint.
+func (e *Engine) Failed() <-chan error {
+	return e.failed
+}
+
+// replicate streams the replication slot to the bus once this server owns it
+func (e *Engine) replicate(ctx context.Context) {
+	fail := func(err error) {
+		select {
+		case e.failed <- err:
+		default:
+		}
+	}
+
+	if err := e.replicator.AwaitSlot(ctx); err != nil {
+		if ctx.Err() == nil {
+			fail(err)
+		}
+		return
+	}
+	changes := make(chan *chat.DataChangeEvent, 100)
+	if err := e.replicator.StartReplication(ctx, changes); err != nil {
+		fail(fmt.Errorf("failed to start replication: %w", err))
+		return
+	}
+	go e.publish(ctx, changes)
+
+	select {
+	case <-ctx.Done():
+	case err := <-e.replicator.Failed():
+		fail(err)
+	}
+}
+
+// publish hands changes to the bus, acknowledging each once the bus has it
+func (e *Engine) publish(ctx context.Context, changes <-chan *chat.DataChangeEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-changes:
+			// Retry until the event is published, it is only acknowledged once on the bus
+			for {
+				err := e.bus.Publish(ctx, event)
+				if err == nil {
+					e.replicator.Acknowledge(event)
+					break
+				}
+				log.Printf("Error publishing event to the %s bus: %v", e.cfg.Bus.Backend, err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}
+}
+
+// broadcast records the events of the bus in the history and fans them out
+func (e *Engine) broadcast(ctx context.Context, busEvents <-chan *chat.DataChangeEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-busEvents:
+			if !ok {
+				return
+			}
+			e.mu.Lock()
+			e.history.Append(event)
+			if event.GetTimestamp() != nil {
+				e.head = event.GetTimestamp().AsTime()
+			}
+			e.mu.Unlock()
+			// Publishing may wait for slow clients, which must not hold up the rest
+			e.hub.Publish(event)
+		}
+	}
+}
+
+// Connect opens a session for a client, assigning it an id unless it brings its
+// own. Ids still streaming are refused with session.ErrDuplicateClient unless the
+// client resumes its session.
+func (e *Engine) Connect(ctx context.Context, req *chat.ConnectRequest) (*chat.ConnectResponse, error) {
+	if _, err := replication.ParsePosition(req.GetCursor()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	published, err := e.replicator.PublishedTables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published tables: %w", err)
+	}
+
+	sess, err := e.sessions.Open(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if sess.Origin != "" {
+		log.Printf("Client %s connected as origin %s with protocol version %d", sess.ID, sess.Origin, sess.ProtocolVersion)
+	} else {
+		log.Printf("Client %s connected with protocol version %d", sess.ID, sess.ProtocolVersion)
+	}
+	return &chat.ConnectResponse{
+		ClientId:        sess.ID,
+		ServerId:        e.cfg.Server.ID,
+		Backend:         e.cfg.Bus.Backend,
+		PublishedTables: published,
+		SessionToken:    sess.Token,
+		ProtocolVersion: sess.ProtocolVersion,
+		Capabilities:    sess.Capabilities,
+	}, nil
+}
+
+// ListSessions describes the sessions of the connected clients
+func (e *Engine) ListSessions(ctx context.Context, req *chat.ListSessionsRequest) (*chat.ListSessionsResponse, error) {
+	e.mu.Lock()
+	head := e.head
+	e.mu.Unlock()
+
+	resp := &chat.ListSessionsResponse{}
+	for _, sess := range e.sessions.List() {
+		resp.Sessions = append(resp.Sessions, sess.Info(head))
+	}
+	return resp, nil
+}
+
+// Stream sends the changes req asks for until ctx is done or send fails. Clients
+// falling behind are disconnected with fanout.ErrSlowConsumer, and clients
+// resuming from a position the history no longer holds are refused with
+// replication.ErrPositionUnavailable. The stream belongs to the session of its
+// token, and ends with session.ErrReplaced once another stream takes it over.
+func (e *Engine) Stream(ctx context.Context, req *chat.StreamDataChangesRequest, send func(*chat.DataChangeEvent) error) error {
+	return e.stream(ctx, req, nil, send)
+}
+
+// Sync is Stream under the flow control of the acks the client sends: nothing is
+// sent before the first ack, then only as many events as its window allows past the
+// position it applied. The slowest client's applied position holds back the history
+// and the replication slot, so it can resume from there even after a restart.
+func (e *Engine) Sync(ctx context.Context, req *chat.StreamDataChangesRequest, acks <-chan *chat.Ack, send func(*chat.DataChangeEvent) error) error {
+	return e.stream(ctx, req, acks, send)
+}
+
+// stream serves Stream, and Sync if acks is not nil
+func (e *Engine) stream(ctx context.Context, req *chat.StreamDataChangesRequest, acks <-chan *chat.Ack, send func(*chat.DataChangeEvent) error) error {
+	resumeFrom, err := replication.ParsePosition(req.GetResumePosition())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if cursor := req.GetResumeCursor(); cursor != "" && !events.ValidCursor(cursor) {
+		return fmt.Errorf("%w: invalid resume cursor %q", ErrInvalidRequest, cursor)
+	}
+
+	// Only stream the tables the client asked for
+	published, err := e.replicator.PublishedTables(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list published tables: %w", err)
+	}
+	tables, err := replication.NewTableFilter(req.GetTables(), published)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	var relations map[string]*chat.RelationEvent
+	if len(req.GetRowFilters()) > 0 {
+		if relations, err = e.replicator.DescribeTables(ctx); err != nil {
+			return fmt.Errorf("failed to describe published tables: %w", err)
+		}
+	}
+	predicates, err := replication.NewRowFilters(req.GetRowFilters(), tables, published, relations)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	projections, err := replication.NewProjections(req.GetColumns(), tables, published)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	sub := replication.NewSubscription(tables, predicates, projections)
+
+	sess, ctx, detach, err := e.sessions.Attach(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		detach()
+		// The client no longer holds anything back
+		if acks != nil {
+			e.retain()
+		}
+	}()
+
+	var flow *flow
+	if acks != nil {
+		// Until the first ack, the client has applied what it resumes from
+		flow = newFlow(e.cfg.Fanout.AckTimeout, req.GetResumeCursor())
+		cursor := flow.ack(req.GetResumePosition(), 0)
+		sess.Acked(req.GetResumePosition(), cursor, 0)
+		e.retain()
+
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go e.receiveAcks(ctx, cancel, sess, flow, acks)
+	}
+
+	// deliver passes event through the subscription and sends whatever is left of it
+	deliver := func(event *chat.DataChangeEvent) error {
+		for _, filtered := range sub.Filter(event) {
+			if flow != nil {
+				if err := flow.admit(ctx, filtered); err != nil {
+					if ctx.Err() != nil {
+						return context.Cause(ctx)
+					}
+					return err
+				}
+			}
+			if err := send(filtered); err != nil {
+				return fmt.Errorf("failed to send event: %w", err)
+			}
+		}
+		sess.Sent(event)
+		return nil
+	}
+
+	log.Printf("Client %s started streaming", sess.ID)
+	defer log.Printf("Client %s stopped streaming", sess.ID)
+
+	// Clients resume from their cursor on a bus that still holds the event at it,
+	// and from the history otherwise
+	replayer, _ := e.bus.(events.Replayer)
+	resumeCursor := ""
+	if replayer != nil && req.GetResumeCursor() != "" {
+		retained, err := replayer.Retains(ctx, req.GetResumeCursor())
+		if err != nil {
+			return err
+		}
+		if retained {
+			resumeCursor = req.GetResumeCursor()
+		}
+	}
+
+	// New clients can start from a snapshot of the published tables
+	if resumeFrom.IsZero() && resumeCursor == "" && req.GetInitialSnapshot() {
+		resumeFrom, err = e.replicator.Snapshot(ctx, tables, deliver)
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send snapshot: %w", err)
+		}
+	}
+
+	// Subscribe, taking the events the client missed from the history under the
+	// same lock so none are lost. Events are published after they are added to the
+	// history, so the subscriber may still get some of those missed.
+	var missed []*chat.DataChangeEvent
+	e.mu.Lock()
+	if resumeCursor == "" && !resumeFrom.IsZero() {
+		missed, err = e.history.Since(resumeFrom)
+		if err != nil {
+			e.mu.Unlock()
+			return err
+		}
+	}
+	sub.Seed(e.history.Relations())
+	subscriber := e.hub.Subscribe()
+	e.mu.Unlock()
+	defer subscriber.Close()
+	sess.Track(subscriber)
+
+	// Replay missed events, then send live events to the client. Replaying from the
+	// bus reaches past events the subscriber gets too, which are skipped.
+	caughtUp := ""
+	for _, event := range missed {
+		if err := deliver(event); err != nil {
+			return err
+		}
+		caughtUp = event.Position
+	}
+	replayed := ""
+	if resumeCursor != "" {
+		replayed, err = replayer.Replay(ctx, resumeCursor, deliver)
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		event, err := subscriber.Next(ctx)
+		if errors.Is(err, fanout.ErrSlowConsumer) {
+			log.Printf("Disconnecting slow client %s: %v", sess.ID, err)
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		if replayed != "" && event.Cursor != "" && !events.CursorLess(replayed, event.Cursor) {
+			continue
+		}
+		if caughtUp != "" && event.Position != "" && event.Position <= caughtUp {
+			continue
+		}
+		if err := deliver(event); err != nil {
+			return err
+		}
+	}
+}
+
+// receiveAcks applies the acks of a flow controlled stream until ctx is done or the
+// client stops sending them, cancelling the stream on an invalid one
+func (e *Engine) receiveAcks(ctx context.Context, cancel context.CancelCauseFunc, sess *session.Session, flow *flow, acks <-chan *chat.Ack) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ack, ok := <-acks:
+			if !ok {
+				return
+			}
+			if _, err := replication.ParsePosition(ack.GetAppliedPosition()); err != nil {
+				cancel(fmt.Errorf("%w: %v", ErrInvalidRequest, err))
+				return
+			}
+			cursor := flow.ack(ack.GetAppliedPosition(), ack.GetWindow())
+			sess.Acked(ack.GetAppliedPosition(), cursor, ack.GetWindow())
+			e.retain()
+		}
+	}
+}
+
+// retain holds back the history, the replication slot and the bus, if it keeps
+// events, for the slowest client acknowledging what it applied
+func (e *Engine) retain() {
+	e.retaining.Lock()
+	defer e.retaining.Unlock()
+
+	// Acks are validated, the slowest position parses
+	position, cursor := e.sessions.Slowest()
+	slowest, _ := replication.ParsePosition(position)
+	if slowest != e.retained {
+		e.retained = slowest
+		e.history.Retain(slowest)
+		e.replicator.Retain(slowest)
+	}
+	if cursor != e.retainedCursor {
+		e.retainedCursor = cursor
+		if replayer, ok := e.bus.(events.Replayer); ok {
+			replayer.Retain(cursor)
+		}
+	}
+}
+
+// Submit applies a batch of mutations to published tables in one transaction
+func (e *Engine) Submit(ctx context.Context, req *chat.SubmitChangesRequest) (*chat.SubmitChangesResponse, error) {
+	published, err := e.replicator.PublishedTables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published tables: %w", err)
+	}
+	return replication.SubmitMutations(ctx, e.db, e.replicator.SystemID(), e.replicator.Masks(), published, req.GetMutations()), nil
+}
+
+// Push applies a change pushed by a replica, resolving conflicts with the source.
+// Changes that may apply when pushed again return replication.ErrPushUnavailable.
+func (e *Engine) Push(ctx context.Context, push *chat.PushChangesRequest) (*chat.ChangeResult, error) {
+	result, err := e.pushes.Apply(ctx, push)
+	if err != nil {
+		log.Printf("Error applying change to %s from %s: %v", push.GetChange().GetTable(), push.GetOrigin(), err)
+		return nil, err
+	}
+	if result.Status == chat.ChangeStatus_CHANGE_STATUS_FAILED {
+		log.Printf("Failed to apply change to %s from %s: %s", push.GetChange().GetTable(), push.GetOrigin(), result.Message)
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/fanout"
+)
+
+// flow limits the events in flight to a client to the credit window of its last
+// ack. Events are in flight from when they are sent until an ack covers their
+// position. Credit is only taken between transactions, so a transaction the client
+// applies at once is never cut off by the window. Snapshots are a single
+// transaction as large as the tables streamed, and take credit row by row.
+type flow struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	window int
+	acked  string
+	// Bus cursor of the last event acknowledged that has one
+	ackedCursor string
+	// Events in flight, oldest first
+	inFlight []inFlight
+	// Signalled on every ack
+	acks chan struct{}
+
+	// Only used by the sending goroutine
+	inTxn bool
+}
+
+// inFlight is the position and bus cursor of an event sent but not acknowledged
+type inFlight struct {
+	position string
+	cursor   string
+}
+
+// newFlow creates the flow of a client resuming from cursor
+func newFlow(timeout time.Duration, cursor string) *flow {
+	return &flow{timeout: timeout, ackedCursor: cursor, acks: make(chan struct{}, 1)}
+}
+
+// ack releases the events at or before position and sets the window. It returns
+// the bus cursor the client acknowledged, empty if none is known.
+func (f *flow) ack(position string, window uint32) string {
+	f.mu.Lock()
+	if position > f.acked {
+		f.acked = position
+	}
+	released := 0
+	for released < len(f.inFlight) && f.inFlight[released].position <= f.acked {
+		if cursor := f.inFlight[released].cursor; cursor != "" {
+			f.ackedCursor = cursor
+		}
+		released++
+	}
+	f.inFlight = f.inFlight[released:]
+	f.window = int(window)
+	cursor := f.ackedCursor
+	f.mu.Unlock()
+
+	select {
+	case f.acks <- struct{}{}:
+	default:
+	}
+	return cursor
+}
+
+// admit waits for credit to send event, unless it belongs to a transaction already
+// started, and records it as in flight. Clients granting no credit for the ack
+// timeout are given up on with fanout.ErrSlowConsumer.
+func (f *flow) admit(ctx context.Context, event *chat.DataChangeEvent) error {
+	if !f.inTxn {
+		if err := f.wait(ctx); err != nil {
+			return err
+		}
+	}
+	switch event.Operation {
+	case chat.Operation_OPERATION_BEGIN:
+		f.inTxn = !event.GetTransaction().GetSnapshot()
+	case chat.Operation_OPERATION_COMMIT:
+		f.inTxn = false
+	}
+
+	f.mu.Lock()
+	if event.Position > f.acked {
+		f.inFlight = append(f.inFlight, inFlight{position: event.Position, cursor: event.Cursor})
+	}
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *flow) wait(ctx context.Context) error {
+	var expired <-chan time.Time
+	for {
+		f.mu.Lock()
+		credit := len(f.inFlight) < f.window
+		f.mu.Unlock()
+		if credit {
+			return nil
+		}
+
+		if expired == nil {
+			timer := time.NewTimer(f.timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+		select {
+		case <-f.acks:
+		case <-expired:
+			return fmt.Errorf("%w: no credit granted for %s", fanout.ErrSlowConsumer, f.timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
+)
+
+// Bus carries the events of the replication stream from the replicator to the
+// servers streaming them to clients
+type Bus interface {
+	// Publish hands event to the bus. Once it returns, the event is the bus's to
+	// deliver and can be acknowledged to the replicator.
+	Publish(ctx context.Context, event *chat.DataChangeEvent) error
+	// Subscribe returns the events published from now on, in order. The channel is
+	// closed once ctx is done.
+	Subscribe(ctx context.Context) (<-chan *chat.DataChangeEvent, error)
+	Close() error
+}
+
+// Replayer is a Bus that keeps the events it carries, stamped with a cursor
+// clients can resume from
+type Replayer interface {
+	// Retains reports whether the bus still holds the event at cursor
+	Retains(ctx context.Context, cursor string) (bool, error)
+	// Replay sends the events after cursor, oldest first, up to the newest one. It
+	// returns the cursor of the last event sent, or cursor if there was none.
+	Replay(ctx context.Context, cursor string, send func(*chat.DataChangeEvent) error) (string, error)
+	// Retain keeps the events after cursor, which a subscriber has yet to
+	// acknowledge, even past the bus's limit. An empty cursor releases them.
+	Retain(cursor string)
+}
+
+// NewBus connects to the bus backend configured by SYNCER_BUS_BACKEND
+func NewBus(cfg *config.Config) (Bus, error) {
+	switch cfg.Bus.Backend {
+	case "memory", "":
+		return NewMemoryBus(), nil
+	case "redis":
+		return NewRedisEventManager(cfg)
+	case "redis-streams":
+		return NewRedisStreamBus(cfg)
+	}
+	return nil, fmt.Errorf("unknown event bus backend %q", cfg.Bus.Backend)
+}
+
+// MemoryBus passes events within the process, to a single subscriber
+type MemoryBus struct {
+	events     chan *chat.DataChangeEvent
+	subscribed chan struct{}
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		events:     make(chan *chat.DataChangeEvent, 100),
+		subscribed: make(chan struct{}, 1),
+	}
+}
+
+// Publish waits for room in the buffer until ctx is done
+func (b *MemoryBus) Publish(ctx context.Context, event *chat.DataChangeEvent) error {
+	select {
+	case b.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context) (<-chan *chat.DataChangeEvent, error) {
+	select {
+	case b.subscribed <- struct{}{}:
+	default:
+		return nil, errors.New("in-memory bus already has a subscriber")
+	}
+
+	eventChan := make(chan *chat.DataChangeEvent)
+	go func() {
+		defer close(eventChan)
+		defer func() { <-b.subscribed }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-b.events:
+				select {
+				case eventChan <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return eventChan, nil
+}
+
+func (b *MemoryBus) Close() error {
+	return nil
+}
@@ -2,27 +2,44 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
-	"syncer-playground/pkg/chat"
-	"syncer-playground/pkg/config"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/replication"
 )
 
 const (
 	dataChangeChannel = "data_changes"
+	checkpointPrefix  = "syncer:checkpoint:"
 )
 
+// RedisEventManager is a Bus on Redis Pub/Sub, which also keeps replication
+// checkpoints. Events published while a server is disconnected are lost to it; the
+// Redis stream bus delivers them.
 type RedisEventManager struct {
 	client *redis.Client
 }
 
 func NewRedisEventManager(cfg *config.Config) (*RedisEventManager, error) {
+	client, err := connectRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisEventManager{
+		client: client,
+	}, nil
+}
+
+// connectRedis connects to the configured Redis server
+func connectRedis(cfg *config.Config) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
 		Password: cfg.Redis.Password,
@@ -34,17 +51,16 @@ func NewRedisEventManager(cfg *config.Config) (*RedisEventManager, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
-
-	return &RedisEventManager{
-		client: client,
-	}, nil
+	return client, nil
 }
 
-// PublishEvent publishes a data change event to Redis
-func (m *RedisEventManager) PublishEvent(ctx context.Context, event *chat.DataChangeEvent) error {
-	data, err := json.Marshal(event)
+// Publish publishes a data change event to Redis
+func (m *RedisEventManager) Publish(ctx context.Context, event *chat.DataChangeEvent) error {
+	// Row values are oneofs, which only the protobuf JSON mapping round-trips
+	data, err := protojson.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
@@ -56,8 +72,8 @@ func (m *RedisEventManager) PublishEvent(ctx context.Context, event *chat.DataCh
 	return nil
 }
 
-// SubscribeToEvents subscribes to data change events from Redis
-func (m *RedisEventManager) SubscribeToEvents(ctx context.Context) (<-chan *chat.DataChangeEvent, error) {
+// Subscribe subscribes to data change events from Redis
+func (m *RedisEventManager) Subscribe(ctx context.Context) (<-chan *chat.DataChangeEvent, error) {
 	pubsub := m.client.Subscribe(ctx, dataChangeChannel)
 	eventChan := make(chan *chat.DataChangeEvent, 100)
 
@@ -72,7 +88,7 @@ func (m *RedisEventManager) SubscribeToEvents(ctx context.Context) (<-chan *chat
 				return
 			case msg := <-pubsub.Channel():
 				var event chat.DataChangeEvent
-				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				if err := protojson.Unmarshal([]byte(msg.Payload), &event); err != nil {
 					log.Printf("Error unmarshaling event: %v", err)
 					continue
 				}
@@ -82,7 +98,11 @@ func (m *RedisEventManager) SubscribeToEvents(ctx context.Context) (<-chan *chat
 					event.Timestamp = timestamppb.Now()
 				}
 
-				eventChan <- &event
+				select {
+				case eventChan <- &event:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
 	}()
@@ -90,6 +110,35 @@ func (m *RedisEventManager) SubscribeToEvents(ctx context.Context) (<-chan *chat
 	return eventChan, nil
 }
 
+// LoadCheckpoint returns the replication checkpoint saved for slot, or the zero checkpoint if there is none
+func (m *RedisEventManager) LoadCheckpoint(ctx context.Context, slot string) (replication.Checkpoint, error) {
+	return loadCheckpoint(ctx, m.client, slot)
+}
+
+// SaveCheckpoint stores the replication checkpoint for slot
+func (m *RedisEventManager) SaveCheckpoint(ctx context.Context, slot string, cp replication.Checkpoint) error {
+	return saveCheckpoint(ctx, m.client, slot, cp)
+}
+
+func loadCheckpoint(ctx context.Context, client *redis.Client, slot string) (replication.Checkpoint, error) {
+	values, err := client.HGetAll(ctx, checkpointPrefix+slot).Result()
+	if err != nil {
+		return replication.Checkpoint{}, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	if len(values) == 0 {
+		return replication.Checkpoint{}, nil
+	}
+	return replication.ParseCheckpoint(values["lsn"], values["position"])
+}
+
+func saveCheckpoint(ctx context.Context, client *redis.Client, slot string, cp replication.Checkpoint) error {
+	err := client.HSet(ctx, checkpointPrefix+slot, "lsn", cp.LSN.String(), "position", cp.Position.String()).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
 func (m *RedisEventManager) Close() error {
 	return m.client.Close()
 } 
\ No newline at end of file
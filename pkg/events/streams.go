@@ -0,0 +1,361 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/replication"
+)
+
+const (
+	// Field of a stream entry holding the encoded event
+	eventField = "event"
+	// Entries read or replayed per round trip
+	streamBatchSize = 100
+	// How long a read waits for new entries, which bounds how long Subscribe takes
+	// to notice ctx is done
+	streamReadBlock = 5 * time.Second
+)
+
+// RedisStreamBus is a Bus on a Redis stream, trimmed to about its maximum length
+// but never past the entry a subscriber has yet to acknowledge. Every server
+// instance reads the stream through a consumer group named after its id and
+// acknowledges each entry once the engine has it, so the events published while
+// an instance is down reach it once it is back: delivery is at least once. Stream
+// ids are the cursors of the events, and clients can resume from them for as long
+// as the stream holds them. It needs Redis 6.2 or later.
+type RedisStreamBus struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+	group  string
+	// Consumer of this process in the group
+	consumer string
+
+	mu sync.Mutex
+	// Cursor of the oldest entry a subscriber has yet to acknowledge, if any
+	retained string
+}
+
+func NewRedisStreamBus(cfg *config.Config) (*RedisStreamBus, error) {
+	client, err := connectRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Processes of an instance come and go under consumers of their own, whose
+	// unacknowledged entries the next one reclaims
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to name stream consumer: %w", err)
+	}
+
+	return &RedisStreamBus{
+		client:   client,
+		stream:   cfg.Bus.Stream,
+		maxLen:   cfg.Bus.StreamMaxLen,
+		group:    cfg.Server.ID,
+		consumer: cfg.Server.ID + "-" + hex.EncodeToString(suffix),
+	}, nil
+}
+
+// Publish appends event to the stream, trimming it to about its maximum length.
+// While an entry is retained, the stream is only trimmed up to it.
+func (b *RedisStreamBus) Publish(ctx context.Context, event *chat.DataChangeEvent) error {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	b.mu.Lock()
+	retained := b.retained
+	b.mu.Unlock()
+
+	args := &redis.XAddArgs{
+		Stream: b.stream,
+		Values: map[string]interface{}{eventField: data},
+	}
+	if retained == "" {
+		args.MaxLen = b.maxLen
+		args.Approx = true
+	}
+	if err := b.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to add event to stream %s: %w", b.stream, err)
+	}
+	if retained == "" {
+		return nil
+	}
+
+	// The event is on the stream, failing to trim it only delays the trimming
+	length, err := b.client.XLen(ctx, b.stream).Result()
+	if err == nil && length > b.maxLen {
+		err = b.client.XTrimMinIDApprox(ctx, b.stream, retained, 0).Err()
+	}
+	if err != nil {
+		log.Printf("Error trimming stream %s: %v", b.stream, err)
+	}
+	return nil
+}
+
+// Retain keeps the entry at cursor and the ones after it when trimming, which lets
+// the stream grow past its maximum length. An empty cursor releases them.
+func (b *RedisStreamBus) Retain(cursor string) {
+	b.mu.Lock()
+	b.retained = cursor
+	b.mu.Unlock()
+}
+
+// Subscribe returns the events of the stream the consumer group of this instance
+// has not acknowledged yet, in order: the entries earlier processes read but did
+// not acknowledge, then the entries never read. A new group starts with the oldest
+// entry the stream holds, so a server whose group was lost misses nothing it kept.
+func (b *RedisStreamBus) Subscribe(ctx context.Context) (<-chan *chat.DataChangeEvent, error) {
+	err := b.client.XGroupCreateMkStream(ctx, b.stream, b.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("failed to create consumer group %s: %w", b.group, err)
+	}
+
+	eventChan := make(chan *chat.DataChangeEvent)
+	go func() {
+		defer close(eventChan)
+
+		if err := b.reclaim(ctx, eventChan); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error reclaiming pending entries of stream %s: %v", b.stream, err)
+			}
+			return
+		}
+
+		for {
+			streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    b.group,
+				Consumer: b.consumer,
+				Streams:  []string{b.stream, ">"},
+				Count:    streamBatchSize,
+				Block:    streamReadBlock,
+			}).Result()
+			if ctx.Err() != nil {
+				return
+			}
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error reading stream %s: %v", b.stream, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+
+			for _, stream := range streams {
+				if err := b.deliver(ctx, stream.Messages, eventChan); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return eventChan, nil
+}
+
+// reclaim claims the entries earlier processes of this instance read but did not
+// acknowledge and delivers them, then forgets the consumers left without any. The
+// group belongs to this instance, so entries are claimed however recently they were
+// read.
+func (b *RedisStreamBus) reclaim(ctx context.Context, out chan<- *chat.DataChangeEvent) error {
+	start := "0-0"
+	for {
+		// The reply of XAUTOCLAIM grew a third element in Redis 7, which the client
+		// library does not parse
+		reply, err := b.client.Do(ctx, "xautoclaim", b.stream, b.group, b.consumer, 0, start, "count", streamBatchSize).Slice()
+		if err != nil {
+			return fmt.Errorf("failed to claim pending entries: %w", err)
+		}
+		next, messages, err := parseClaimed(reply)
+		if err != nil {
+			return err
+		}
+		if err := b.deliver(ctx, messages, out); err != nil {
+			return err
+		}
+		if next == "0-0" {
+			break
+		}
+		start = next
+	}
+
+	consumers, err := b.client.XInfoConsumers(ctx, b.stream, b.group).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list consumers of group %s: %w", b.group, err)
+	}
+	for _, consumer := range consumers {
+		if consumer.Name == b.consumer || consumer.Pending > 0 {
+			continue
+		}
+		if err := b.client.XGroupDelConsumer(ctx, b.stream, b.group, consumer.Name).Err(); err != nil {
+			log.Printf("Error removing consumer %s of group %s: %v", consumer.Name, b.group, err)
+		}
+	}
+	return nil
+}
+
+// deliver sends the events of messages, each stamped with its stream id as cursor,
+// and acknowledges every message once sent. Entries that do not decode are logged
+// and acknowledged, they would never decode.
+func (b *RedisStreamBus) deliver(ctx context.Context, messages []redis.XMessage, out chan<- *chat.DataChangeEvent) error {
+	for _, msg := range messages {
+		event, err := decodeEntry(msg)
+		if err != nil {
+			log.Printf("Error decoding entry %s of stream %s: %v", msg.ID, b.stream, err)
+		} else {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := b.client.XAck(ctx, b.stream, b.group, msg.ID).Err(); err != nil {
+			log.Printf("Error acknowledging entry %s of stream %s: %v", msg.ID, b.stream, err)
+		}
+	}
+	return nil
+}
+
+// Retains reports whether the stream still holds the event at cursor, so that
+// replaying the events after it skips none
+func (b *RedisStreamBus) Retains(ctx context.Context, cursor string) (bool, error) {
+	messages, err := b.client.XRangeN(ctx, b.stream, cursor, cursor, 1).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to look up entry %s of stream %s: %w", cursor, b.stream, err)
+	}
+	return len(messages) == 1, nil
+}
+
+// Replay sends the events of the stream after cursor, oldest first, until it
+// reaches the end of the stream. It returns the cursor of the last event sent, or
+// cursor if there was none.
+func (b *RedisStreamBus) Replay(ctx context.Context, cursor string, send func(*chat.DataChangeEvent) error) (string, error) {
+	for {
+		messages, err := b.client.XRangeN(ctx, b.stream, "("+cursor, "+", streamBatchSize).Result()
+		if err != nil {
+			return cursor, fmt.Errorf("failed to read stream %s: %w", b.stream, err)
+		}
+		for _, msg := range messages {
+			event, err := decodeEntry(msg)
+			if err != nil {
+				return cursor, fmt.Errorf("failed to decode entry %s of stream %s: %w", msg.ID, b.stream, err)
+			}
+			if err := send(event); err != nil {
+				return cursor, err
+			}
+			cursor = msg.ID
+		}
+		if len(messages) < streamBatchSize {
+			return cursor, nil
+		}
+	}
+}
+
+// LoadCheckpoint returns the replication checkpoint saved for slot, or the zero checkpoint if there is none
+func (b *RedisStreamBus) LoadCheckpoint(ctx context.Context, slot string) (replication.Checkpoint, error) {
+	return loadCheckpoint(ctx, b.client, slot)
+}
+
+// SaveCheckpoint stores the replication checkpoint for slot
+func (b *RedisStreamBus) SaveCheckpoint(ctx context.Context, slot string, cp replication.Checkpoint) error {
+	return saveCheckpoint(ctx, b.client, slot, cp)
+}
+
+func (b *RedisStreamBus) Close() error {
+	return b.client.Close()
+}
+
+// decodeEntry decodes the event of a stream entry, stamped with the entry's id
+func decodeEntry(msg redis.XMessage) (*chat.DataChangeEvent, error) {
+	data, ok := msg.Values[eventField].(string)
+	if !ok {
+		return nil, fmt.Errorf("entry has no %s field", eventField)
+	}
+	event := &chat.DataChangeEvent{}
+	if err := proto.Unmarshal([]byte(data), event); err != nil {
+		return nil, err
+	}
+	event.Cursor = msg.ID
+	return event, nil
+}
+
+// parseClaimed parses the reply of XAUTOCLAIM: the id to continue from and the
+// claimed entries. Entries trimmed from the stream meanwhile come back empty on
+// Redis 6.2 and are skipped.
+func parseClaimed(reply []interface{}) (string, []redis.XMessage, error) {
+	if len(reply) < 2 {
+		return "", nil, fmt.Errorf("unexpected XAUTOCLAIM reply of %d elements", len(reply))
+	}
+	next, ok := reply[0].(string)
+	entries, ok2 := reply[1].([]interface{})
+	if !ok || !ok2 {
+		return "", nil, fmt.Errorf("unexpected XAUTOCLAIM reply %v", reply)
+	}
+
+	var messages []redis.XMessage
+	for _, entry := range entries {
+		fields, ok := entry.([]interface{})
+		if !ok || len(fields) != 2 {
+			continue
+		}
+		id, _ := fields[0].(string)
+		pairs, _ := fields[1].([]interface{})
+		values := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if key, ok := pairs[i].(string); ok {
+				values[key] = pairs[i+1]
+			}
+		}
+		messages = append(messages, redis.XMessage{ID: id, Values: values})
+	}
+	return next, messages, nil
+}
+
+// CursorLess reports whether the stream id a comes before b
+func CursorLess(a, b string) bool {
+	aMillis, aSeq := splitCursor(a)
+	bMillis, bSeq := splitCursor(b)
+	if aMillis != bMillis {
+		return aMillis < bMillis
+	}
+	return aSeq < bSeq
+}
+
+// ValidCursor reports whether cursor is a stream id
+func ValidCursor(cursor string) bool {
+	millis, seq, ok := strings.Cut(cursor, "-")
+	if !ok {
+		return false
+	}
+	_, err := strconv.ParseUint(millis, 10, 64)
+	_, err2 := strconv.ParseUint(seq, 10, 64)
+	return err == nil && err2 == nil
+}
+
+func splitCursor(cursor string) (uint64, uint64) {
+	millis, seq, _ := strings.Cut(cursor, "-")
+	m, _ := strconv.ParseUint(millis, 10, 64)
+	s, _ := strconv.ParseUint(seq, 10, 64)
+	return m, s
+}
@@ -0,0 +1,302 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// ErrSlowConsumer is returned to a subscriber that fell too far behind and was
+// disconnected. It can resume from the position of the last event it received.
+var ErrSlowConsumer = errors.New("subscriber fell behind")
+
+// errClosed disconnects a subscriber that unsubscribed
+var errClosed = errors.New("subscriber closed")
+
+// Policy is what a Hub does with a subscriber whose queue is full
+type Policy int
+
+const (
+	// Disconnect drops the subscriber with ErrSlowConsumer
+	Disconnect Policy = iota
+	// Block waits for room in the queue up to the block timeout, then disconnects
+	// the subscriber
+	Block
+	// Spill writes the events that do not fit to a file, read back once the
+	// subscriber catches up
+	Spill
+)
+
+// ParsePolicy returns the policy called name
+func ParsePolicy(name string) (Policy, error) {
+	switch name {
+	case "disconnect", "":
+		return Disconnect, nil
+	case "block":
+		return Block, nil
+	case "spill":
+		return Spill, nil
+	}
+	return 0, fmt.Errorf("unknown slow consumer policy %q", name)
+}
+
+func (p Policy) String() string {
+	switch p {
+	case Disconnect:
+		return "disconnect"
+	case Block:
+		return "block"
+	case Spill:
+		return "spill"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// Options configure a Hub
+type Options struct {
+	// Events queued for each subscriber before the policy applies
+	QueueSize int
+	Policy    Policy
+	// How long Block waits for room in the queues of a published event
+	BlockTimeout time.Duration
+	// Directory spill files are created in, the system temporary directory if empty
+	SpillDir string
+	// Size a spill file may grow to before its subscriber is disconnected, 0 for no limit
+	SpillLimit int64
+}
+
+// Hub broadcasts events to subscribers consuming them at their own pace. Each
+// subscriber has a bounded queue, and the policy decides what happens once it is
+// full; events are never dropped while the subscriber stays connected.
+type Hub struct {
+	opts Options
+	// Serializes Publish, so every subscriber sees events in the same order
+	publishing  sync.Mutex
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+}
+
+// NewHub creates a hub without subscribers
+func NewHub(opts Options) *Hub {
+	if opts.QueueSize < 1 {
+		opts.QueueSize = 1
+	}
+	return &Hub{opts: opts, subscribers: make(map[*Subscriber]struct{})}
+}
+
+// Subscribe registers a subscriber receiving every event published from now on.
+// It must be closed once done with.
+func (h *Hub) Subscribe() *Subscriber {
+	s := &Subscriber{
+		hub:     h,
+		queue:   make(chan *chat.DataChangeEvent, h.opts.QueueSize),
+		spilled: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.subscribers[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Publish delivers event to every subscriber, disconnecting those the policy gives
+// up on. Under Block it waits for full queues until the block timeout, which starts
+// once for all of them.
+func (h *Hub) Publish(event *chat.DataChangeEvent) {
+	h.publishing.Lock()
+	defer h.publishing.Unlock()
+
+	h.mu.Lock()
+	subscribers := make([]*Subscriber, 0, len(h.subscribers))
+	for s := range h.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	h.mu.Unlock()
+
+	var expired context.Context
+	cancel := func() {}
+	defer func() { cancel() }()
+	deadline := func() <-chan struct{} {
+		if expired == nil {
+			expired, cancel = context.WithTimeout(context.Background(), h.opts.BlockTimeout)
+		}
+		return expired.Done()
+	}
+
+	for _, s := range subscribers {
+		if err := s.offer(event, deadline); err != nil {
+			s.disconnect(err)
+		}
+	}
+}
+
+// remove unregisters s
+func (h *Hub) remove(s *Subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, s)
+	h.mu.Unlock()
+}
+
+// Subscriber receives the events published to a Hub, in order
+type Subscriber struct {
+	hub   *Hub
+	queue chan *chat.DataChangeEvent
+	// Signalled when an event is spilled
+	spilled chan struct{}
+	// Closed once the subscriber is disconnected, err tells why
+	done chan struct{}
+	once sync.Once
+	err  error
+
+	mu sync.Mutex
+	// Events that did not fit the queue, created on first use. While it holds
+	// events, newer ones are spilled too to keep them in order.
+	spill *spillFile
+}
+
+// Next returns the next event, waiting for one until ctx is done. Once the
+// subscriber is disconnected it returns the reason, ErrSlowConsumer if it fell
+// behind.
+func (s *Subscriber) Next(ctx context.Context) (*chat.DataChangeEvent, error) {
+	for {
+		select {
+		case <-s.done:
+			return nil, s.err
+		default:
+		}
+		// Queued events are older than spilled ones
+		select {
+		case event := <-s.queue:
+			return event, nil
+		default:
+		}
+		event, err := s.unspill()
+		if err != nil {
+			s.disconnect(err)
+			return nil, err
+		}
+		if event != nil {
+			return event, nil
+		}
+
+		select {
+		case event := <-s.queue:
+			return event, nil
+		case <-s.spilled:
+		case <-s.done:
+			return nil, s.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Depth returns the number of events waiting for the subscriber, queued or spilled
+func (s *Subscriber) Depth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	depth := len(s.queue)
+	if s.spill != nil {
+		depth += s.spill.count
+	}
+	return depth
+}
+
+// Close unsubscribes and removes the spill file, if any
+func (s *Subscriber) Close() {
+	s.disconnect(errClosed)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spill != nil {
+		s.spill.close()
+		s.spill = nil
+	}
+}
+
+// offer queues event, applying the policy if the queue is full. deadline returns a
+// channel closed once Block has waited long enough.
+func (s *Subscriber) offer(event *chat.DataChangeEvent, deadline func() <-chan struct{}) error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	switch s.hub.opts.Policy {
+	case Block:
+		select {
+		case s.queue <- event:
+			return nil
+		default:
+		}
+		select {
+		case s.queue <- event:
+			return nil
+		case <-s.done:
+			return nil
+		case <-deadline():
+			return fmt.Errorf("%w: queue stayed full for %s", ErrSlowConsumer, s.hub.opts.BlockTimeout)
+		}
+
+	case Spill:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-s.done:
+			return nil
+		default:
+		}
+		if s.spill == nil || s.spill.empty() {
+			select {
+			case s.queue <- event:
+				return nil
+			default:
+			}
+		}
+		if s.spill == nil {
+			spill, err := newSpillFile(s.hub.opts.SpillDir, s.hub.opts.SpillLimit)
+			if err != nil {
+				return err
+			}
+			s.spill = spill
+		}
+		if err := s.spill.push(event); err != nil {
+			return err
+		}
+		select {
+		case s.spilled <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+	}
+	return fmt.Errorf("%w: queue of %d events is full", ErrSlowConsumer, cap(s.queue))
+}
+
+// unspill returns the oldest spilled event, nil if there is none
+func (s *Subscriber) unspill() (*chat.DataChangeEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spill == nil || s.spill.empty() {
+		return nil, nil
+	}
+	return s.spill.pop()
+}
+
+// disconnect stops s for err, the first reason given wins
+func (s *Subscriber) disconnect(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+	s.hub.remove(s)
+}
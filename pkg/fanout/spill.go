@@ -0,0 +1,88 @@
+package fanout
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// spillFile is a queue of events in a file. Each record is the size of the encoded
+// event in 4 bytes, big endian, followed by the event.
+type spillFile struct {
+	file *os.File
+	// Offsets of the next record to read and to write
+	read, write int64
+	limit       int64
+	// Number of events in the file
+	count int
+}
+
+// newSpillFile creates an empty spill file in dir. It refuses events once it would
+// grow past limit bytes, unless limit is 0.
+func newSpillFile(dir string, limit int64) (*spillFile, error) {
+	file, err := os.CreateTemp(dir, "syncer-spill-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	return &spillFile{file: file, limit: limit}, nil
+}
+
+func (f *spillFile) empty() bool {
+	return f.read == f.write
+}
+
+// push appends event
+func (f *spillFile) push(event *chat.DataChangeEvent) error {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	record := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data)))
+	record = append(record, data...)
+	if f.limit > 0 && f.write+int64(len(record)) > f.limit {
+		return fmt.Errorf("%w: spill file reached %d bytes", ErrSlowConsumer, f.write)
+	}
+	if _, err := f.file.WriteAt(record, f.write); err != nil {
+		return fmt.Errorf("failed to spill event: %w", err)
+	}
+	f.write += int64(len(record))
+	f.count++
+	return nil
+}
+
+// pop removes and returns the oldest event. The file is truncated whenever it is
+// read to the end, so it only grows while its subscriber stays behind.
+func (f *spillFile) pop() (*chat.DataChangeEvent, error) {
+	var size [4]byte
+	if _, err := f.file.ReadAt(size[:], f.read); err != nil {
+		return nil, fmt.Errorf("failed to read spilled event: %w", err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := f.file.ReadAt(data, f.read+4); err != nil {
+		return nil, fmt.Errorf("failed to read spilled event: %w", err)
+	}
+	event := &chat.DataChangeEvent{}
+	if err := proto.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to decode spilled event: %w", err)
+	}
+
+	f.read += 4 + int64(len(data))
+	f.count--
+	if f.empty() {
+		if err := f.file.Truncate(0); err != nil {
+			return nil, fmt.Errorf("failed to truncate spill file: %w", err)
+		}
+		f.read, f.write = 0, 0
+	}
+	return event, nil
+}
+
+// close closes and removes the file
+func (f *spillFile) close() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
@@ -0,0 +1,89 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckpointTable is the table PostgresCheckpointer stores confirmed LSNs in
+const CheckpointTable = "syncer_checkpoints"
+
+// Checkpoint records how far the changes of a slot have been handed off downstream
+type Checkpoint struct {
+	// LSN up to which every transaction was handed off
+	LSN pglogrepl.LSN
+	// Position of the last event handed off. Every event after it is still in the slot.
+	Position Position
+}
+
+// Checkpointer persists the last checkpoint of a replication slot
+type Checkpointer interface {
+	// LoadCheckpoint returns the saved checkpoint for slot, or the zero checkpoint if there is none
+	LoadCheckpoint(ctx context.Context, slot string) (Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, slot string, cp Checkpoint) error
+}
+
+type checkpointRow struct {
+	Slot      string `gorm:"primaryKey"`
+	LSN       string `gorm:"type:pg_lsn;not null"`
+	Position  string `gorm:"not null;default:''"`
+	UpdatedAt time.Time
+}
+
+func (checkpointRow) TableName() string {
+	return CheckpointTable
+}
+
+// PostgresCheckpointer stores checkpoints in the syncer_checkpoints table
+type PostgresCheckpointer struct {
+	db *gorm.DB
+}
+
+func NewPostgresCheckpointer(db *gorm.DB) (*PostgresCheckpointer, error) {
+	if err := db.AutoMigrate(&checkpointRow{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate %s: %w", CheckpointTable, err)
+	}
+	return &PostgresCheckpointer{db: db}, nil
+}
+
+func (c *PostgresCheckpointer) LoadCheckpoint(ctx context.Context, slot string) (Checkpoint, error) {
+	var row checkpointRow
+	err := c.db.WithContext(ctx).Where("slot = ?", slot).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Checkpoint{}, nil
+	}
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return ParseCheckpoint(row.LSN, row.Position)
+}
+
+func (c *PostgresCheckpointer) SaveCheckpoint(ctx context.Context, slot string, cp Checkpoint) error {
+	err := c.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slot"}},
+		DoUpdates: clause.AssignmentColumns([]string{"lsn", "position", "updated_at"}),
+	}).Create(&checkpointRow{Slot: slot, LSN: cp.LSN.String(), Position: cp.Position.String()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ParseCheckpoint builds a checkpoint from its stored string forms
+func ParseCheckpoint(lsn, position string) (Checkpoint, error) {
+	var cp Checkpoint
+	var err error
+	if cp.LSN, err = pglogrepl.ParseLSN(lsn); err != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint LSN: %w", err)
+	}
+	if cp.Position, err = ParsePosition(position); err != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint position: %w", err)
+	}
+	return cp, nil
+}
@@ -0,0 +1,134 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// DDLTable is the table the DDL capture trigger records statements in. Being part
+// of the publication, the statements reach the stream in commit order with the
+// row changes around them.
+const DDLTable = "syncer_ddl"
+
+// ddlCaptureSQL installs the capture table and the event triggers feeding it.
+// Statements run with syncer.capture set to off are not captured, which keeps
+// this setup itself out of the stream.
+const ddlCaptureSQL = `
+SET syncer.capture = off;
+
+CREATE TABLE IF NOT EXISTS ` + DDLTable + ` (
+	id bigserial PRIMARY KEY,
+	command_tag text NOT NULL,
+	tables text[] NOT NULL DEFAULT '{}',
+	ddl text NOT NULL,
+	captured_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION syncer_capture_ddl() RETURNS event_trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+	affected text[];
+BEGIN
+	IF current_setting('syncer.capture', true) = 'off' THEN
+		RETURN;
+	END IF;
+
+	IF TG_EVENT = 'sql_drop' THEN
+		-- ALTER TABLE ... DROP COLUMN drops objects too, it is captured at command end
+		IF TG_TAG NOT LIKE 'DROP%' THEN
+			RETURN;
+		END IF;
+		SELECT coalesce(array_agg(DISTINCT schema_name || '.' || object_name), '{}') INTO affected
+		FROM pg_event_trigger_dropped_objects()
+		WHERE object_type = 'table';
+	ELSE
+		-- Drops are captured by sql_drop, which still knows the dropped tables
+		IF TG_TAG LIKE 'DROP%' THEN
+			RETURN;
+		END IF;
+		SELECT coalesce(array_agg(DISTINCT n.nspname || '.' || c.relname), '{}') INTO affected
+		FROM pg_event_trigger_ddl_commands() cmd
+		JOIN pg_class c ON c.oid = coalesce((SELECT indrelid FROM pg_index WHERE indexrelid = cmd.objid), cmd.objid)
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE cmd.classid = 'pg_class'::regclass AND c.relkind IN ('r', 'p');
+	END IF;
+
+	INSERT INTO ` + DDLTable + ` (command_tag, tables, ddl) VALUES (TG_TAG, affected, current_query());
+END
+$$;
+
+DROP EVENT TRIGGER IF EXISTS syncer_capture_ddl;
+CREATE EVENT TRIGGER syncer_capture_ddl ON ddl_command_end EXECUTE FUNCTION syncer_capture_ddl();
+DROP EVENT TRIGGER IF EXISTS syncer_capture_drop;
+CREATE EVENT TRIGGER syncer_capture_drop ON sql_drop EXECUTE FUNCTION syncer_capture_ddl();
+`
+
+// setupDDLCapture installs the DDL capture table and triggers. Event triggers can
+// only be created by a superuser.
+func (r *PostgresReplicator) setupDDLCapture(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, r.cfg.GetPostgresDSN())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.PgConn().Exec(ctx, ddlCaptureSQL).ReadAll(); err != nil {
+		return fmt.Errorf("failed to set up DDL capture: %w", err)
+	}
+	log.Printf("DDL capture installed in %s", DDLTable)
+	return nil
+}
+
+// internalTable reports whether table holds the replicator's own bookkeeping, whose
+// changes are not streamed as rows
+func internalTable(table string) bool {
+	switch table {
+	case CheckpointTable, DDLTable, RowOriginsTable, ConflictsTable, PushedChangesTable:
+		return true
+	}
+	return false
+}
+
+// newSchemaChangeEvent turns a row inserted by the DDL capture trigger into a schema
+// change event. Statements on the replicator's own tables are left out.
+func (s *stream) newSchemaChangeEvent(rel *pglogrepl.RelationMessage, tuple *pglogrepl.TupleData) (*chat.DataChangeEvent, error) {
+	change := &chat.SchemaChangeEvent{}
+	for idx, col := range tuple.Columns {
+		if col.DataType != pglogrepl.TupleDataTypeText {
+			continue
+		}
+		switch rel.Columns[idx].Name {
+		case "command_tag":
+			change.CommandTag = string(col.Data)
+		case "ddl":
+			change.Ddl = string(col.Data)
+		case "tables":
+			if err := s.typeMap.Scan(pgtype.TextArrayOID, pgtype.TextFormatCode, col.Data, &change.Tables); err != nil {
+				return nil, fmt.Errorf("failed to decode captured tables: %w", err)
+			}
+		}
+	}
+
+	for _, table := range change.Tables {
+		if internalTable(table[strings.LastIndex(table, ".")+1:]) {
+			return nil, nil
+		}
+	}
+
+	event := &chat.DataChangeEvent{
+		Operation:    chat.Operation_OPERATION_SCHEMA_CHANGE,
+		SchemaChange: change,
+	}
+	if len(change.Tables) > 0 {
+		event.Table = change.Tables[0]
+	}
+	return event, nil
+}
@@ -0,0 +1,334 @@
+package replication
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// ErrUnknownTable is returned when a table filter matches no published table
+var ErrUnknownTable = errors.New("unknown table")
+
+// TableFilter matches schema-qualified table names against the patterns a
+// subscriber asked for
+type TableFilter struct {
+	patterns []string
+}
+
+// NewTableFilter builds a filter from table names or glob patterns such as
+// "public.order_*". Unqualified patterns refer to the public schema. Every pattern
+// must match at least one of the published tables. No patterns match every table.
+func NewTableFilter(patterns []string, published []string) (*TableFilter, error) {
+	f := &TableFilter{}
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, ".") {
+			pattern = "public." + pattern
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid table pattern %q: %w", pattern, err)
+		}
+
+		known := false
+		for _, table := range published {
+			if ok, _ := path.Match(pattern, table); ok {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTable, pattern)
+		}
+		f.patterns = append(f.patterns, pattern)
+	}
+	return f, nil
+}
+
+// Match reports whether the schema-qualified table passes the filter
+func (f *TableFilter) Match(table string) bool {
+	if f == nil || len(f.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range f.patterns {
+		if ok, _ := path.Match(pattern, table); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRowFilters parses row predicates keyed by table name. The tables must have
+// REPLICA IDENTITY FULL: only then does the old row of a delete or update show
+// whether the row matched, and the change can reach the subscribers having it.
+func NewRowFilters(filters map[string]string, tables *TableFilter, published []string, relations map[string]*chat.RelationEvent) (map[string]*Predicate, error) {
+	predicates := make(map[string]*Predicate, len(filters))
+	for name, filter := range filters {
+		table, err := resolveTable(name, tables, published)
+		if err != nil {
+			return nil, err
+		}
+		if relations[table].GetReplicaIdentity() != chat.ReplicaIdentity_REPLICA_IDENTITY_FULL {
+			return nil, fmt.Errorf("row filter for %s needs REPLICA IDENTITY FULL", table)
+		}
+		predicate, err := ParsePredicate(filter, relations[table])
+		if err != nil {
+			return nil, fmt.Errorf("invalid row filter for %s: %w", table, err)
+		}
+		predicates[table] = predicate
+	}
+	return predicates, nil
+}
+
+// NewProjections builds the sets of columns to stream keyed by table name
+func NewProjections(columns map[string]*chat.ColumnList, tables *TableFilter, published []string) (map[string]map[string]bool, error) {
+	projections := make(map[string]map[string]bool, len(columns))
+	for name, list := range columns {
+		table, err := resolveTable(name, tables, published)
+		if err != nil {
+			return nil, err
+		}
+		if len(list.GetNames()) == 0 {
+			return nil, fmt.Errorf("no columns selected for %s", table)
+		}
+		projection := make(map[string]bool, len(list.GetNames()))
+		for _, column := range list.GetNames() {
+			projection[column] = true
+		}
+		projections[table] = projection
+	}
+	return projections, nil
+}
+
+// resolveTable qualifies a table name from a request, which must name a published
+// table passing the table filter. Unqualified names refer to the public schema.
+func resolveTable(name string, tables *TableFilter, published []string) (string, error) {
+	if !strings.Contains(name, ".") {
+		name = "public." + name
+	}
+	for _, table := range published {
+		if table == name && tables.Match(table) {
+			return table, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownTable, name)
+}
+
+// Subscription filters the change stream for one subscriber. Transactions left
+// without row changes are dropped along with their BEGIN and COMMIT.
+type Subscription struct {
+	tables      *TableFilter
+	predicates  map[string]*Predicate
+	projections map[string]map[string]bool
+	// BEGIN of the current transaction, held back until one of its rows passes
+	begin *chat.DataChangeEvent
+	rows  uint32
+	// Latest relation of each table, and whether the subscriber has received it
+	relations map[uint32]*chat.RelationEvent
+	sent      map[uint32]bool
+}
+
+func NewSubscription(tables *TableFilter, predicates map[string]*Predicate, projections map[string]map[string]bool) *Subscription {
+	return &Subscription{
+		tables:      tables,
+		predicates:  predicates,
+		projections: projections,
+		relations:   make(map[uint32]*chat.RelationEvent),
+		sent:        make(map[uint32]bool),
+	}
+}
+
+// Seed records relation events for tables the subscription has not seen a relation
+// of yet, such as those described before it started
+func (s *Subscription) Seed(relations []*chat.DataChangeEvent) {
+	for _, event := range relations {
+		if _, ok := s.relations[event.RelationId]; !ok {
+			s.Filter(event)
+		}
+	}
+}
+
+// Filter returns the events to send to the subscriber for event, in order.
+// Relations are held back until a row change of their table passes.
+func (s *Subscription) Filter(event *chat.DataChangeEvent) []*chat.DataChangeEvent {
+	switch event.Operation {
+	case chat.Operation_OPERATION_RELATION:
+		if s.tables.Match(event.Table) {
+			s.relations[event.RelationId] = event.Relation
+			delete(s.sent, event.RelationId)
+		}
+		return nil
+
+	case chat.Operation_OPERATION_BEGIN:
+		s.begin = event
+		s.rows = 0
+		return nil
+
+	case chat.Operation_OPERATION_COMMIT:
+		if s.begin != nil {
+			// Nothing in the transaction passed the filter
+			s.begin = nil
+			return nil
+		}
+		if event.GetTransaction().GetRowCount() == s.rows {
+			return []*chat.DataChangeEvent{event}
+		}
+		// Events are shared between subscribers, report the filtered row count on a copy
+		commit := proto.Clone(event).(*chat.DataChangeEvent)
+		commit.Transaction.RowCount = s.rows
+		return []*chat.DataChangeEvent{commit}
+
+	case chat.Operation_OPERATION_SCHEMA_CHANGE:
+		if !s.matchSchemaChange(event.SchemaChange) {
+			return nil
+		}
+		if s.begin != nil {
+			begin := s.begin
+			s.begin = nil
+			return []*chat.DataChangeEvent{begin, event}
+		}
+		return []*chat.DataChangeEvent{event}
+	}
+
+	if !s.tables.Match(event.Table) {
+		return nil
+	}
+	event = s.filterRow(event)
+	if event == nil {
+		return nil
+	}
+	event = s.project(event)
+	s.rows++
+
+	var events []*chat.DataChangeEvent
+	if s.begin != nil {
+		events = append(events, s.begin)
+		s.begin = nil
+	}
+	// The relation goes out as a copy without the position it had in the stream
+	if relation, ok := s.relations[event.RelationId]; ok && !s.sent[event.RelationId] {
+		if projection, ok := s.projections[event.Table]; ok {
+			relation = projectRelation(relation, projection)
+		}
+		events = append(events, newRelationEvent(relation))
+		s.sent[event.RelationId] = true
+	}
+	return append(events, event)
+}
+
+// matchSchemaChange reports whether a DDL statement touches a subscribed table.
+// Statements naming no table only reach subscribers of every table.
+func (s *Subscription) matchSchemaChange(change *chat.SchemaChangeEvent) bool {
+	if s.tables == nil || len(s.tables.patterns) == 0 {
+		return true
+	}
+	for _, table := range change.GetTables() {
+		if s.tables.Match(table) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRow applies the row predicate of the event's table. It returns nil when the
+// row is outside the predicate, and an INSERT or DELETE in place of an UPDATE that
+// moves the row into or out of it. Filtered tables have REPLICA IDENTITY FULL, so
+// the old tuple shows whether the row matched; should it lack the columns of the
+// predicate anyway, the change is dropped rather than leaking rows of other
+// subscribers.
+func (s *Subscription) filterRow(event *chat.DataChangeEvent) *chat.DataChangeEvent {
+	predicate, ok := s.predicates[event.Table]
+	if !ok {
+		return event
+	}
+
+	newRow := rowValues(event.Data)
+	oldRow := rowValues(event.OldData)
+	oldMatch := oldRow != nil && predicate.Covers(oldRow) && predicate.Match(oldRow)
+
+	switch event.Operation {
+	case chat.Operation_OPERATION_INSERT:
+		if predicate.Match(newRow) {
+			return event
+		}
+		return nil
+
+	case chat.Operation_OPERATION_DELETE:
+		if oldMatch {
+			return event
+		}
+		return nil
+
+	case chat.Operation_OPERATION_UPDATE:
+		// Unchanged TOAST values are left out of the new tuple
+		if newRow == nil {
+			newRow = make(map[string]interface{}, len(oldRow))
+		}
+		for column, value := range oldRow {
+			if _, ok := newRow[column]; !ok {
+				newRow[column] = value
+			}
+		}
+		newMatch := predicate.Match(newRow)
+
+		switch {
+		case newMatch && oldMatch:
+			return event
+		case newMatch:
+			// The subscriber may not have the row, which the client upserts
+			insert := proto.Clone(event).(*chat.DataChangeEvent)
+			insert.Operation = chat.Operation_OPERATION_INSERT
+			insert.OldData = nil
+			return insert
+		case oldMatch:
+			// The row left the predicate, only its key is sent
+			remove := proto.Clone(event).(*chat.DataChangeEvent)
+			remove.Operation = chat.Operation_OPERATION_DELETE
+			remove.OldData = keyRow(remove.OldData, event.KeyColumns)
+			remove.Data = nil
+			return remove
+		}
+		return nil
+	}
+	return event
+}
+
+// keyRow leaves only the key columns in row, or all of them if the table has no key
+func keyRow(row *chat.Row, keyColumns []string) *chat.Row {
+	if len(keyColumns) == 0 {
+		return row
+	}
+	return projectRow(row, nil, keyColumns)
+}
+
+// project leaves only the selected columns of the event's table in its row images
+func (s *Subscription) project(event *chat.DataChangeEvent) *chat.DataChangeEvent {
+	projection, ok := s.projections[event.Table]
+	if !ok {
+		return event
+	}
+
+	projected := proto.Clone(event).(*chat.DataChangeEvent)
+	projected.Data = projectRow(projected.Data, projection, event.KeyColumns)
+	projected.OldData = projectRow(projected.OldData, projection, event.KeyColumns)
+	return projected
+}
+
+// projectRow leaves only the selected and key columns in row
+func projectRow(row *chat.Row, projection map[string]bool, keyColumns []string) *chat.Row {
+	if row == nil {
+		return nil
+	}
+	columns := row.Columns[:0]
+	for _, column := range row.Columns {
+		if projection[column.Name] || slices.Contains(keyColumns, column.Name) {
+			columns = append(columns, column)
+		}
+	}
+	row.Columns = columns
+	return row
+}
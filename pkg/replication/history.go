@@ -0,0 +1,126 @@
+package replication
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// ErrPositionUnavailable is returned when a resume position is older than the
+// retained history, so resuming from it would skip changes
+var ErrPositionUnavailable = errors.New("resume position is no longer retained")
+
+// History retains the most recent events so reconnecting clients can resume
+type History struct {
+	mu sync.RWMutex
+	// Ring buffer of events, the oldest at start
+	events []*chat.DataChangeEvent
+	start  int
+	count  int
+	// Events at or before floor are not retained
+	floor Position
+	// Events after retain are kept past the size, see Retain
+	retain Position
+	// Latest relation event of each table, kept past eviction
+	relations map[uint32]*chat.DataChangeEvent
+}
+
+// NewHistory creates a history holding up to size events. Events at or before floor
+// are treated as already gone.
+func NewHistory(size int, floor Position) *History {
+	if size < 0 {
+		size = 0
+	}
+	return &History{
+		events:    make([]*chat.DataChangeEvent, size),
+		floor:     floor,
+		relations: make(map[uint32]*chat.DataChangeEvent),
+	}
+}
+
+// Append records an event, evicting the oldest one when the history is full
+func (h *History) Append(event *chat.DataChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if event.Operation == chat.Operation_OPERATION_RELATION {
+		h.relations[event.RelationId] = event
+	}
+	if len(h.events) == 0 {
+		h.evict(event)
+		return
+	}
+	if h.count == len(h.events) && h.retained(h.events[h.start]) {
+		h.grow()
+	}
+	if h.count == len(h.events) {
+		h.evict(h.events[h.start])
+		h.events[h.start] = event
+		h.start = (h.start + 1) % len(h.events)
+		return
+	}
+	h.events[(h.start+h.count)%len(h.events)] = event
+	h.count++
+}
+
+// Retain keeps the events after pos, which a subscriber has yet to acknowledge,
+// growing the history rather than evicting them. The zero position keeps the
+// history to its size.
+func (h *History) Retain(pos Position) {
+	h.mu.Lock()
+	h.retain = pos
+	h.mu.Unlock()
+}
+
+func (h *History) retained(event *chat.DataChangeEvent) bool {
+	return !h.retain.IsZero() && event.Position > h.retain.String()
+}
+
+// grow doubles the size of the history
+func (h *History) grow() {
+	events := make([]*chat.DataChangeEvent, 2*len(h.events))
+	for i := 0; i < h.count; i++ {
+		events[i] = h.events[(h.start+i)%len(h.events)]
+	}
+	h.events = events
+	h.start = 0
+}
+
+func (h *History) evict(event *chat.DataChangeEvent) {
+	if pos, err := ParsePosition(event.Position); err == nil {
+		h.floor = pos
+	}
+}
+
+// Since returns the retained events after pos, oldest first
+func (h *History) Since(pos Position) ([]*chat.DataChangeEvent, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if pos.Less(h.floor) {
+		return nil, ErrPositionUnavailable
+	}
+
+	after := pos.String()
+	var events []*chat.DataChangeEvent
+	for i := 0; i < h.count; i++ {
+		event := h.events[(h.start+i)%len(h.events)]
+		if event.Position > after {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// Relations returns the latest relation event of every table seen
+func (h *History) Relations() []*chat.DataChangeEvent {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	relations := make([]*chat.DataChangeEvent, 0, len(h.relations))
+	for _, event := range h.relations {
+		relations = append(relations, event)
+	}
+	return relations
+}
@@ -0,0 +1,109 @@
+package replication
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ColumnMasks redacts or hashes configured columns before rows leave the replicator.
+// Redacted columns are left out of the row images entirely, hashed columns carry the
+// hex HMAC-SHA256 of their value as text.
+type ColumnMasks struct {
+	redactions map[string]map[string]bool
+	hashes     map[string]map[string]bool
+	key        []byte
+}
+
+// NewColumnMasks builds masks from column names such as "users.email" or
+// "billing.cards.number". Unqualified tables refer to the public schema.
+func NewColumnMasks(redact, hash []string, key string) (*ColumnMasks, error) {
+	m := &ColumnMasks{key: []byte(key)}
+
+	var err error
+	if m.redactions, err = parseMaskedColumns(redact); err != nil {
+		return nil, err
+	}
+	if m.hashes, err = parseMaskedColumns(hash); err != nil {
+		return nil, err
+	}
+	if len(m.hashes) > 0 && key == "" {
+		return nil, errors.New("hashed columns require a hash key")
+	}
+	return m, nil
+}
+
+func parseMaskedColumns(columns []string) (map[string]map[string]bool, error) {
+	masked := make(map[string]map[string]bool)
+	for _, name := range columns {
+		dot := strings.LastIndex(name, ".")
+		if dot <= 0 || dot == len(name)-1 {
+			return nil, fmt.Errorf("invalid masked column %q, expected table.column", name)
+		}
+		table, column := name[:dot], name[dot+1:]
+		if !strings.Contains(table, ".") {
+			table = "public." + table
+		}
+		if masked[table] == nil {
+			masked[table] = make(map[string]bool)
+		}
+		masked[table][column] = true
+	}
+	return masked, nil
+}
+
+func (m *ColumnMasks) redacted(table, column string) bool {
+	return m != nil && m.redactions[table][column]
+}
+
+func (m *ColumnMasks) hashed(table, column string) bool {
+	return m != nil && m.hashes[table][column]
+}
+
+func (m *ColumnMasks) masked(table, column string) bool {
+	return m.redacted(table, column) || m.hashed(table, column)
+}
+
+// unmask drops the masked columns of table from a row written back to the source,
+// which only hold their hash or nothing, and returns their names in order
+func (m *ColumnMasks) unmask(table string, row map[string]interface{}) []string {
+	var dropped []string
+	for column := range row {
+		if m.masked(table, column) {
+			delete(row, column)
+			dropped = append(dropped, column)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// maskedKey returns the first masked column of keyColumns, if any
+func (m *ColumnMasks) maskedKey(table string, keyColumns []string) (string, bool) {
+	for _, column := range keyColumns {
+		if m.masked(table, column) {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// unmaskedMessage tells which masked columns a write left out
+func unmaskedMessage(dropped []string) string {
+	if len(dropped) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("masked columns %s were not written", strings.Join(dropped, ", "))
+}
+
+// hash hashes a value in its Postgres text form, so hashes can be matched against
+// values hashed elsewhere
+func (m *ColumnMasks) hash(text []byte) string {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write(text)
+	return hex.EncodeToString(mac.Sum(nil))
+}
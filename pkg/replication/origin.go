@@ -0,0 +1,37 @@
+package replication
+
+import (
+	"fmt"
+	"path"
+)
+
+// OriginFilter matches the replication origins of syncer peers. Transactions a peer
+// applied on the source came from the stream to begin with, streaming them again
+// would echo them back forever.
+type OriginFilter struct {
+	patterns []string
+}
+
+// NewOriginFilter builds a filter from origin names or glob patterns such as "syncer_*"
+func NewOriginFilter(patterns []string) (*OriginFilter, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid origin pattern %q: %w", pattern, err)
+		}
+	}
+	return &OriginFilter{patterns: patterns}, nil
+}
+
+// Match reports whether origin is a peer's. Changes made on the source itself have
+// no origin and never match.
+func (f *OriginFilter) Match(origin string) bool {
+	if f == nil || origin == "" {
+		return false
+	}
+	for _, pattern := range f.patterns {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
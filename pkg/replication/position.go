@@ -0,0 +1,46 @@
+package replication
+
+import (
+	"fmt"
+
+	"github.com/jackc/pglogrepl"
+)
+
+// Position identifies a change in the stream: the commit LSN of its transaction and
+// the index of the change within that transaction. Its string form is fixed width so
+// that positions compare in byte order.
+type Position struct {
+	LSN pglogrepl.LSN
+	Seq uint32
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%016X/%08X", uint64(p.LSN), p.Seq)
+}
+
+func (p Position) IsZero() bool {
+	return p == Position{}
+}
+
+// Less reports whether p comes before other in the stream
+func (p Position) Less(other Position) bool {
+	if p.LSN != other.LSN {
+		return p.LSN < other.LSN
+	}
+	return p.Seq < other.Seq
+}
+
+// ParsePosition parses a position as produced by Position.String. An empty string
+// is the zero position.
+func ParsePosition(s string) (Position, error) {
+	if s == "" {
+		return Position{}, nil
+	}
+
+	var lsn uint64
+	var seq uint32
+	if _, err := fmt.Sscanf(s, "%016X/%08X", &lsn, &seq); err != nil || len(s) != 25 {
+		return Position{}, fmt.Errorf("invalid position %q", s)
+	}
+	return Position{LSN: pglogrepl.LSN(lsn), Seq: seq}, nil
+}
@@ -1 +1,775 @@
- 
\ No newline at end of file
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/config"
+)
+
+const (
+	outputPlugin          = "pgoutput"
+	standbyMessageTimeout = 10 * time.Second
+)
+
+type PostgresReplicator struct {
+	cfg          *config.Config
+	conn         *pgconn.PgConn
+	checkpointer Checkpointer
+	masks        *ColumnMasks
+	peers        *OriginFilter
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	// Receives the error a replication stream failed with
+	failed chan error
+	// System identifier of the source database, which row versions carry
+	system string
+
+	mu     sync.Mutex
+	active *stream
+	// Position the slot is held at for the slowest subscriber, if any
+	retain Position
+	// Connection holding the lock on the slot, once this process owns it
+	owner *pgx.Conn
+}
+
+func NewPostgresReplicator(cfg *config.Config, checkpointer Checkpointer) (*PostgresReplicator, error) {
+	masks, err := NewColumnMasks(cfg.Masking.Redact, cfg.Masking.Hash, cfg.Masking.HashKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure column masking: %w", err)
+	}
+	peers, err := NewOriginFilter(cfg.Replication.PeerOrigins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure peer origins: %w", err)
+	}
+
+	// Connect to PostgreSQL
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sysident, err := pglogrepl.IdentifySystem(ctx, conn)
+	if err != nil {
+		conn.Close(context.Background())
+		return nil, fmt.Errorf("failed to identify system: %w", err)
+	}
+
+	replicatorCtx, replicatorCancel := context.WithCancel(context.Background())
+	return &PostgresReplicator{
+		cfg:          cfg,
+		conn:         conn,
+		checkpointer: checkpointer,
+		masks:        masks,
+		peers:        peers,
+		ctx:          replicatorCtx,
+		cancel:       replicatorCancel,
+		failed:       make(chan error, 1),
+		system:       sysident.SystemID,
+	}, nil
+}
+
+// Masks returns the masks applied to the columns streamed
+func (r *PostgresReplicator) Masks() *ColumnMasks {
+	return r.masks
+}
+
+// SystemID returns the system identifier of the source database
+func (r *PostgresReplicator) SystemID() string {
+	return r.system
+}
+
+// connect opens a connection in logical replication mode
+func connect(ctx context.Context, cfg *config.Config) (*pgconn.PgConn, error) {
+	conn, err := pgconn.Connect(ctx, cfg.GetPostgresDSN()+" replication=database")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replication connection: %w", err)
+	}
+	return conn, nil
+}
+
+// SetupReplication creates the publication and the replication slot if they do not exist
+// yet, and installs DDL capture if enabled
+func (r *PostgresReplicator) SetupReplication(ctx context.Context) error {
+	publication := r.cfg.Replication.Publication
+	slot := r.cfg.Replication.Slot
+
+	exists, err := r.exists(ctx, fmt.Sprintf("SELECT 1 FROM pg_publication WHERE pubname = %s", quoteLiteral(publication)))
+	if err != nil {
+		return fmt.Errorf("failed to look up publication %s: %w", publication, err)
+	}
+	if !exists {
+		sql := fmt.Sprintf("CREATE PUBLICATION %s FOR ALL TABLES", pgx.Identifier{publication}.Sanitize())
+		if _, err := r.conn.Exec(ctx, sql).ReadAll(); err != nil {
+			return fmt.Errorf("failed to create publication %s: %w", publication, err)
+		}
+		log.Printf("Created publication %s", publication)
+	}
+
+	exists, err = r.exists(ctx, fmt.Sprintf("SELECT 1 FROM pg_replication_slots WHERE slot_name = %s", quoteLiteral(slot)))
+	if err != nil {
+		return fmt.Errorf("failed to look up replication slot %s: %w", slot, err)
+	}
+	if !exists {
+		_, err := pglogrepl.CreateReplicationSlot(ctx, r.conn, slot, outputPlugin, pglogrepl.CreateReplicationSlotOptions{
+			Mode: pglogrepl.LogicalReplication,
+		})
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "42710":
+			// Another server sharing the slot created it first
+		case err != nil:
+			return fmt.Errorf("failed to create replication slot %s: %w", slot, err)
+		default:
+			log.Printf("Created replication slot %s", slot)
+		}
+	}
+
+	if r.cfg.Replication.CaptureDDL {
+		if err := r.setupDDLCapture(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AwaitSlot blocks until this process owns the replication slot, or ctx is done.
+// Servers sharing a slot through the bus only stream from it one at a time: the
+// owner holds an advisory lock on the slot name for as long as the replicator is
+// open, and the others wait for it, taking over once the owner is gone.
+func (r *PostgresReplicator) AwaitSlot(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, r.cfg.GetPostgresDSN())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	lock := "syncer_slot:" + r.cfg.Replication.Slot
+	var owned bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", lock).Scan(&owned); err != nil {
+		conn.Close(context.Background())
+		return fmt.Errorf("failed to lock replication slot %s: %w", r.cfg.Replication.Slot, err)
+	}
+	if !owned {
+		log.Printf("Replication slot %s is owned by another server, waiting to take over", r.cfg.Replication.Slot)
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1))", lock); err != nil {
+			conn.Close(context.Background())
+			return fmt.Errorf("failed to lock replication slot %s: %w", r.cfg.Replication.Slot, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.owner != nil {
+		conn.Close(context.Background())
+		return nil
+	}
+	r.owner = conn
+	log.Printf("Owning replication slot %s", r.cfg.Replication.Slot)
+	return nil
+}
+
+// StartReplication starts streaming changes from the replication slot into events,
+// resuming after the last checkpoint. Streaming runs in the background until ctx is
+// cancelled or the replicator is closed. Every event sent must be passed to
+// Acknowledge once it has been handed off, otherwise the slot never advances.
+func (r *PostgresReplicator) StartReplication(ctx context.Context, events chan<- *chat.DataChangeEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active != nil {
+		return fmt.Errorf("replication slot %s is already streaming", r.cfg.Replication.Slot)
+	}
+
+	cp, err := r.checkpointer.LoadCheckpoint(ctx, r.cfg.Replication.Slot)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connect(ctx, r.cfg)
+	if err != nil {
+		return err
+	}
+
+	err = pglogrepl.StartReplication(ctx, conn, r.cfg.Replication.Slot, cp.LSN, pglogrepl.StartReplicationOptions{
+		Mode: pglogrepl.LogicalReplication,
+		PluginArgs: []string{
+			"proto_version '1'",
+			fmt.Sprintf("publication_names %s", quoteLiteral(r.cfg.Replication.Publication)),
+		},
+	})
+	if err != nil {
+		conn.Close(context.Background())
+		return fmt.Errorf("failed to start replication on slot %s: %w", r.cfg.Replication.Slot, err)
+	}
+	log.Printf("Logical replication started on slot %s at %s", r.cfg.Replication.Slot, cp.LSN)
+
+	// Relation messages are completed from the catalog
+	catalog, err := pgx.Connect(ctx, r.cfg.GetPostgresDSN())
+	if err != nil {
+		conn.Close(context.Background())
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	// Transactions streamed are close to the next one, which widens their ids
+	xidRef, err := nextXid(ctx, catalog)
+	if err != nil {
+		conn.Close(context.Background())
+		catalog.Close(context.Background())
+		return err
+	}
+
+	s := &stream{
+		conn:         conn,
+		catalog:      catalog,
+		slot:         r.cfg.Replication.Slot,
+		system:       r.system,
+		xidRef:       xidRef,
+		checkpointer: r.checkpointer,
+		masks:        r.masks,
+		peers:        r.peers,
+		events:       events,
+		relations:    make(map[uint32]*pglogrepl.RelationMessage),
+		keys:         make(map[uint32][]string),
+		typeMap:      pgtype.NewMap(),
+		resumeAfter:  cp.Position,
+		confirmed:    cp.LSN,
+		acked:        cp.Position,
+		saved:        cp,
+		retain:       r.retain,
+	}
+	r.active = s
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(r.ctx, cancel)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		defer stop()
+		defer conn.Close(context.Background())
+		defer catalog.Close(context.Background())
+		defer func() {
+			r.mu.Lock()
+			r.active = nil
+			r.mu.Unlock()
+		}()
+
+		err := s.run(streamCtx)
+		if err == nil || streamCtx.Err() != nil {
+			return
+		}
+		select {
+		case r.failed <- fmt.Errorf("replication stream stopped: %w", err):
+		default:
+		}
+	}()
+
+	return nil
+}
+
+// Failed returns a channel receiving the error a replication stream failed with.
+// Streams stopped by cancelling ctx or closing the replicator do not fail.
+func (r *PostgresReplicator) Failed() <-chan error {
+	return r.failed
+}
+
+// Acknowledge marks event, and every event sent before it, as safely handed off.
+// The slot is advanced and checkpointed only up to acknowledged transactions.
+func (r *PostgresReplicator) Acknowledge(event *chat.DataChangeEvent) {
+	r.mu.Lock()
+	s := r.active
+	r.mu.Unlock()
+
+	if s != nil {
+		s.acknowledge(event)
+	}
+}
+
+// Retain holds the slot and its checkpoint at pos, so the changes after it are
+// streamed again after a restart and subscribers can still resume from it. The zero
+// position releases the slot. Neither ever moves back.
+func (r *PostgresReplicator) Retain(pos Position) {
+	r.mu.Lock()
+	r.retain = pos
+	s := r.active
+	r.mu.Unlock()
+
+	if s != nil {
+		s.mu.Lock()
+		s.retain = pos
+		s.dirty = true
+		s.mu.Unlock()
+	}
+}
+
+// ResumeFloor returns the position of the last event handed off before this process
+// started. Clients cannot resume from an earlier position once the history kept in
+// memory is gone.
+func (r *PostgresReplicator) ResumeFloor(ctx context.Context) (Position, error) {
+	cp, err := r.checkpointer.LoadCheckpoint(ctx, r.cfg.Replication.Slot)
+	if err != nil {
+		return Position{}, err
+	}
+	return cp.Position, nil
+}
+
+func (r *PostgresReplicator) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	r.mu.Lock()
+	if r.owner != nil {
+		r.owner.Close(context.Background())
+		r.owner = nil
+	}
+	r.mu.Unlock()
+	return r.conn.Close(context.Background())
+}
+
+// exists reports whether the query returns at least one row
+func (r *PostgresReplicator) exists(ctx context.Context, sql string) (bool, error) {
+	results, err := r.conn.Exec(ctx, sql).ReadAll()
+	if err != nil {
+		return false, err
+	}
+	return len(results) > 0 && len(results[0].Rows) > 0, nil
+}
+
+// stream holds the state of a single START_REPLICATION session
+type stream struct {
+	conn         *pgconn.PgConn
+	catalog      *pgx.Conn
+	slot         string
+	system       string
+	checkpointer Checkpointer
+	masks        *ColumnMasks
+	peers        *OriginFilter
+	events       chan<- *chat.DataChangeEvent
+	relations    map[uint32]*pglogrepl.RelationMessage
+	keys         map[uint32][]string
+	typeMap      *pgtype.Map
+
+	// Newest 64-bit transaction id seen, which the 32-bit ids streamed are widened by
+	xidRef uint64
+
+	// Transaction currently being decoded, the sequence number of the last event sent
+	// for it and the number of row changes among them
+	xid        uint64
+	commitLSN  pglogrepl.LSN
+	commitTime time.Time
+	seq        uint32
+	rows       uint32
+	inTxn      bool
+	// Replication origin of the transaction, and whether it is a peer's whose
+	// row changes are skipped
+	origin   string
+	fromPeer bool
+	// Events up to this position were handed off by a previous session
+	resumeAfter Position
+
+	mu sync.Mutex
+	// Events sent but not acknowledged yet, oldest first
+	pending []*pendingEvent
+	// LSN up to which every change has been handed off
+	confirmed pglogrepl.LSN
+	// Position of the last acknowledged event
+	acked Position
+	// Whether acknowledged events moved the checkpoint since it was last saved
+	dirty bool
+	// Position the checkpoint is held at, see PostgresReplicator.Retain
+	retain Position
+	// Last checkpoint saved
+	saved Checkpoint
+}
+
+type pendingEvent struct {
+	event    *chat.DataChangeEvent
+	position Position
+	// End LSN of the last transaction committed when this event is acknowledged
+	commitLSN pglogrepl.LSN
+}
+
+func (s *stream) run(ctx context.Context) error {
+	nextStandbyMessageDeadline := time.Now().Add(standbyMessageTimeout)
+
+	for {
+		if time.Now().After(nextStandbyMessageDeadline) {
+			if err := s.sendStandbyStatus(ctx); err != nil {
+				return err
+			}
+			nextStandbyMessageDeadline = time.Now().Add(standbyMessageTimeout)
+		}
+
+		receiveCtx, cancel := context.WithDeadline(ctx, nextStandbyMessageDeadline)
+		rawMsg, err := s.conn.ReceiveMessage(receiveCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if pgconn.Timeout(err) {
+				continue
+			}
+			return fmt.Errorf("failed to receive message: %w", err)
+		}
+
+		if errMsg, ok := rawMsg.(*pgproto3.ErrorResponse); ok {
+			return fmt.Errorf("received WAL error: %s", errMsg.Message)
+		}
+
+		msg, ok := rawMsg.(*pgproto3.CopyData)
+		if !ok {
+			log.Printf("Received unexpected message: %T", rawMsg)
+			continue
+		}
+
+		switch msg.Data[0] {
+		case pglogrepl.PrimaryKeepaliveMessageByteID:
+			pkm, err := pglogrepl.ParsePrimaryKeepaliveMessage(msg.Data[1:])
+			if err != nil {
+				return fmt.Errorf("failed to parse keepalive message: %w", err)
+			}
+			// Between transactions with nothing in flight, WAL up to the server's end
+			// holds no changes for us and can be released
+			if !s.inTxn {
+				s.confirmIdle(pkm.ServerWALEnd)
+			}
+			if pkm.ReplyRequested {
+				nextStandbyMessageDeadline = time.Time{}
+			}
+
+		case pglogrepl.XLogDataByteID:
+			xld, err := pglogrepl.ParseXLogData(msg.Data[1:])
+			if err != nil {
+				return fmt.Errorf("failed to parse XLogData: %w", err)
+			}
+
+			if err := s.handle(ctx, xld.WALData); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendStandbyStatus checkpoints the confirmed position and reports it to the server
+func (s *stream) sendStandbyStatus(ctx context.Context) error {
+	s.mu.Lock()
+	dirty := s.dirty
+	cp := s.checkpoint()
+	s.dirty = false
+	s.mu.Unlock()
+
+	if dirty {
+		if err := s.checkpointer.SaveCheckpoint(ctx, s.slot, cp); err != nil {
+			s.mu.Lock()
+			s.dirty = true
+			s.mu.Unlock()
+			return err
+		}
+		s.mu.Lock()
+		s.saved = cp
+		s.mu.Unlock()
+	}
+
+	err := pglogrepl.SendStandbyStatusUpdate(ctx, s.conn, pglogrepl.StandbyStatusUpdate{WALWritePosition: cp.LSN})
+	if err != nil {
+		return fmt.Errorf("failed to send standby status update: %w", err)
+	}
+	return nil
+}
+
+// checkpoint returns the checkpoint to save: the acknowledged changes, held back to
+// the retained position. The transaction of a retained position is kept whole, and
+// the checkpoint never moves back past the one saved. It must be called with s.mu
+// held.
+func (s *stream) checkpoint() Checkpoint {
+	cp := Checkpoint{LSN: s.confirmed, Position: s.acked}
+	if !s.retain.IsZero() && s.retain.Less(cp.Position) {
+		cp.Position = s.retain
+		if s.retain.LSN < cp.LSN {
+			cp.LSN = s.retain.LSN
+		}
+	}
+	if cp.Position.Less(s.saved.Position) {
+		cp.Position = s.saved.Position
+	}
+	if cp.LSN < s.saved.LSN {
+		cp.LSN = s.saved.LSN
+	}
+	return cp
+}
+
+func (s *stream) acknowledge(event *chat.DataChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.pending {
+		if p.event != event {
+			continue
+		}
+		for _, acked := range s.pending[:i+1] {
+			if acked.commitLSN > s.confirmed {
+				s.confirmed = acked.commitLSN
+			}
+		}
+		s.acked = p.position
+		s.dirty = true
+		s.pending = s.pending[i+1:]
+		return
+	}
+}
+
+// commit records that a transaction ending at lsn was handed off once everything sent
+// so far is acknowledged
+func (s *stream) commit(lsn pglogrepl.LSN) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		if lsn > s.confirmed {
+			s.confirmed = lsn
+		}
+		return
+	}
+	s.pending[len(s.pending)-1].commitLSN = lsn
+}
+
+func (s *stream) confirmIdle(lsn pglogrepl.LSN) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 && lsn > s.confirmed {
+		s.confirmed = lsn
+	}
+}
+
+// handle decodes a single pgoutput message and emits the resulting events, if any.
+// BEGIN is only sent ahead of the first event of a transaction, so transactions
+// without changes for us never show up in the stream. The row changes of
+// transactions applied by syncer peers are skipped; their relations still go out,
+// as pgoutput does not describe a table again.
+func (s *stream) handle(ctx context.Context, walData []byte) error {
+	logicalMsg, err := pglogrepl.Parse(walData)
+	if err != nil {
+		return fmt.Errorf("failed to parse logical replication message: %w", err)
+	}
+
+	var event *chat.DataChangeEvent
+	switch logicalMsg := logicalMsg.(type) {
+	case *pglogrepl.RelationMessage:
+		s.relations[logicalMsg.RelationID] = logicalMsg
+		if internalTable(logicalMsg.RelationName) {
+			return nil
+		}
+		relation, err := describeRelation(ctx, s.catalog, s.masks, logicalMsg)
+		if err != nil {
+			return err
+		}
+		s.keys[logicalMsg.RelationID] = keyColumnNames(relation)
+		event = newRelationEvent(relation)
+		event.Timestamp = timestamppb.New(s.commitTime)
+
+	case *pglogrepl.BeginMessage:
+		s.xid = widenXid(s.xidRef, logicalMsg.Xid)
+		s.xidRef = max(s.xidRef, s.xid)
+		s.commitLSN = logicalMsg.FinalLSN
+		s.commitTime = logicalMsg.CommitTime
+		s.seq = 0
+		s.rows = 0
+		s.inTxn = true
+		s.origin = ""
+		s.fromPeer = false
+
+	case *pglogrepl.OriginMessage:
+		s.origin = logicalMsg.Name
+		s.fromPeer = s.peers.Match(logicalMsg.Name)
+
+	case *pglogrepl.CommitMessage:
+		s.inTxn = false
+		if s.seq == 0 {
+			s.commit(logicalMsg.TransactionEndLSN)
+			return nil
+		}
+		commit := &chat.DataChangeEvent{
+			Operation:   chat.Operation_OPERATION_COMMIT,
+			Timestamp:   timestamppb.New(s.commitTime),
+			Transaction: s.transaction(s.rows),
+		}
+		return s.send(ctx, commit, Position{LSN: s.commitLSN, Seq: math.MaxUint32}, logicalMsg.TransactionEndLSN)
+
+	case *pglogrepl.InsertMessage:
+		if s.fromPeer {
+			return nil
+		}
+		event, err = s.newEvent(chat.Operation_OPERATION_INSERT, logicalMsg.RelationID, logicalMsg.Tuple, nil)
+
+	case *pglogrepl.UpdateMessage:
+		if s.fromPeer {
+			return nil
+		}
+		event, err = s.newEvent(chat.Operation_OPERATION_UPDATE, logicalMsg.RelationID, logicalMsg.NewTuple, logicalMsg.OldTuple)
+
+	case *pglogrepl.DeleteMessage:
+		if s.fromPeer {
+			return nil
+		}
+		event, err = s.newEvent(chat.Operation_OPERATION_DELETE, logicalMsg.RelationID, nil, logicalMsg.OldTuple)
+	}
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return nil
+	}
+
+	if s.seq == 0 {
+		begin := &chat.DataChangeEvent{
+			Operation:   chat.Operation_OPERATION_BEGIN,
+			Timestamp:   timestamppb.New(s.commitTime),
+			Transaction: s.transaction(0),
+		}
+		if err := s.send(ctx, begin, Position{LSN: s.commitLSN}, 0); err != nil {
+			return err
+		}
+	}
+	s.seq++
+	if event.Operation != chat.Operation_OPERATION_RELATION && event.Operation != chat.Operation_OPERATION_SCHEMA_CHANGE {
+		s.rows++
+	}
+	return s.send(ctx, event, Position{LSN: s.commitLSN, Seq: s.seq}, 0)
+}
+
+// send stamps event with position and hands it to the consumer. commitLSN is the end
+// of the transaction that event completes, if any.
+func (s *stream) send(ctx context.Context, event *chat.DataChangeEvent, position Position, commitLSN pglogrepl.LSN) error {
+	event.Position = position.String()
+	event.Origin = s.origin
+	if !s.resumeAfter.Less(position) {
+		if commitLSN != 0 {
+			s.commit(commitLSN)
+		}
+		// pgoutput describes each table once per session, so the rows after the
+		// resume position still need the relation. It is not awaiting an ack.
+		if event.Operation == chat.Operation_OPERATION_RELATION {
+			select {
+			case s.events <- event:
+			case <-ctx.Done():
+			}
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, &pendingEvent{event: event, position: position, commitLSN: commitLSN})
+	s.mu.Unlock()
+
+	select {
+	case s.events <- event:
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (s *stream) transaction(rowCount uint32) *chat.Transaction {
+	return &chat.Transaction{
+		Xid:             uint32(s.xid),
+		CommitLsn:       s.commitLSN.String(),
+		CommitTimestamp: timestamppb.New(s.commitTime),
+		RowCount:        rowCount,
+	}
+}
+
+func (s *stream) newEvent(op chat.Operation, relationID uint32, newTuple, oldTuple *pglogrepl.TupleData) (*chat.DataChangeEvent, error) {
+	rel, ok := s.relations[relationID]
+	if !ok {
+		return nil, fmt.Errorf("unknown relation ID %d", relationID)
+	}
+	if rel.RelationName == DDLTable && op == chat.Operation_OPERATION_INSERT {
+		return s.newSchemaChangeEvent(rel, newTuple)
+	}
+	// Bookkeeping writes must not feed back into the stream
+	if internalTable(rel.RelationName) {
+		return nil, nil
+	}
+
+	table := qualifiedName(rel.Namespace, rel.RelationName)
+	data, err := s.encodeTuple(rel, table, newTuple)
+	if err != nil {
+		return nil, err
+	}
+	oldData, err := s.encodeTuple(rel, table, oldTuple)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chat.DataChangeEvent{
+		Operation:  op,
+		Table:      table,
+		Data:       data,
+		OldData:    oldData,
+		KeyColumns: s.keys[relationID],
+		RelationId: rel.RelationID,
+		Timestamp:  timestamppb.New(s.commitTime),
+		RowVersion: rowVersion(s.system, s.xid),
+	}, nil
+}
+
+// encodeTuple decodes the text-format tuple into a typed row
+func (s *stream) encodeTuple(rel *pglogrepl.RelationMessage, table string, tuple *pglogrepl.TupleData) (*chat.Row, error) {
+	if tuple == nil {
+		return nil, nil
+	}
+
+	row := &chat.Row{Columns: make([]*chat.Column, 0, len(tuple.Columns))}
+	for idx, col := range tuple.Columns {
+		column := rel.Columns[idx]
+		var text []byte
+		switch col.DataType {
+		case pglogrepl.TupleDataTypeNull:
+		case pglogrepl.TupleDataTypeToast:
+			// Unchanged TOAST values are not sent, leave the column out
+			continue
+		case pglogrepl.TupleDataTypeText:
+			text = col.Data
+			if text == nil {
+				text = []byte{}
+			}
+		}
+
+		encoded, err := encodeColumn(s.typeMap, s.masks, table, column.Name, column.DataType, text)
+		if err != nil {
+			return nil, err
+		}
+		if encoded != nil {
+			row.Columns = append(row.Columns, encoded)
+		}
+	}
+	return row, nil
+}
+
+// qualifiedName returns the schema-qualified name events refer to a table by
+func qualifiedName(schema, table string) string {
+	return schema + "." + table
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,678 @@
+package replication
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// Predicate is a row filter written as a SQL boolean expression over the columns
+// of a table, such as "tenant_id = 42" or "status IN ('open', 'pending')".
+//
+// Supported are the comparison operators =, <>, !=, <, <=, > and >=, [NOT] IN,
+// IS [NOT] NULL, AND, OR, NOT and parentheses, with number, string, TRUE, FALSE
+// and NULL literals. Columns compare as numbers, booleans or text by their type,
+// and only with operands of the same kind. Comparisons with NULL or with a number
+// that is not finite are unknown, as in SQL, and a row only matches when the
+// predicate is true.
+type Predicate struct {
+	expr    node
+	columns []string
+}
+
+// ParsePredicate parses a predicate expression over the columns of relation
+func ParsePredicate(s string, relation *chat.RelationEvent) (*Predicate, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, kinds: make(map[string]valueKind), columns: make(map[string]bool)}
+	for _, column := range relation.GetColumns() {
+		p.kinds[column.Name] = columnKind(column.TypeOid)
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q in predicate", tok.text)
+	}
+
+	predicate := &Predicate{expr: expr}
+	for column := range p.columns {
+		predicate.columns = append(predicate.columns, column)
+	}
+	return predicate, nil
+}
+
+// Columns returns the columns the predicate refers to
+func (p *Predicate) Columns() []string {
+	return p.columns
+}
+
+// Covers reports whether row holds every column the predicate refers to
+func (p *Predicate) Covers(row map[string]interface{}) bool {
+	for _, column := range p.columns {
+		if _, ok := row[column]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether the predicate is true for row. Missing columns are NULL.
+func (p *Predicate) Match(row map[string]interface{}) bool {
+	return p.expr.eval(row) == triTrue
+}
+
+// rowValues returns the values of a row as the predicate compares them, keyed by
+// column name
+func rowValues(row *chat.Row) map[string]interface{} {
+	if row == nil {
+		return nil
+	}
+	values := make(map[string]interface{}, len(row.GetColumns()))
+	for _, column := range row.GetColumns() {
+		switch v := column.GetValue().GetKind().(type) {
+		case *chat.Value_IntValue:
+			values[column.Name] = json.Number(strconv.FormatInt(v.IntValue, 10))
+		case *chat.Value_FloatValue:
+			values[column.Name] = v.FloatValue
+		case *chat.Value_NumericValue:
+			values[column.Name] = json.Number(v.NumericValue)
+		case *chat.Value_TextValue:
+			values[column.Name] = v.TextValue
+		case *chat.Value_JsonValue:
+			values[column.Name] = v.JsonValue
+		case *chat.Value_BoolValue:
+			values[column.Name] = v.BoolValue
+		case *chat.Value_ByteaValue:
+			values[column.Name] = string(v.ByteaValue)
+		case *chat.Value_TimestampValue:
+			values[column.Name] = v.TimestampValue.AsTime().Format(time.RFC3339Nano)
+		default:
+			values[column.Name] = nil
+		}
+	}
+	return values
+}
+
+// tri is a SQL three-valued logic value
+type tri int
+
+const (
+	triFalse tri = iota
+	triTrue
+	triUnknown
+)
+
+func triOf(b bool) tri {
+	if b {
+		return triTrue
+	}
+	return triFalse
+}
+
+type node interface {
+	eval(row map[string]interface{}) tri
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(row map[string]interface{}) tri {
+	l, r := n.left.eval(row), n.right.eval(row)
+	switch {
+	case l == triFalse || r == triFalse:
+		return triFalse
+	case l == triUnknown || r == triUnknown:
+		return triUnknown
+	}
+	return triTrue
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(row map[string]interface{}) tri {
+	l, r := n.left.eval(row), n.right.eval(row)
+	switch {
+	case l == triTrue || r == triTrue:
+		return triTrue
+	case l == triUnknown || r == triUnknown:
+		return triUnknown
+	}
+	return triFalse
+}
+
+type notNode struct{ expr node }
+
+func (n notNode) eval(row map[string]interface{}) tri {
+	switch n.expr.eval(row) {
+	case triTrue:
+		return triFalse
+	case triFalse:
+		return triTrue
+	}
+	return triUnknown
+}
+
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n compareNode) eval(row map[string]interface{}) tri {
+	cmp, ok := compareValues(n.left.value(row), n.right.value(row))
+	if !ok {
+		return triUnknown
+	}
+	switch n.op {
+	case "=":
+		return triOf(cmp == 0)
+	case "<>", "!=":
+		return triOf(cmp != 0)
+	case "<":
+		return triOf(cmp < 0)
+	case "<=":
+		return triOf(cmp <= 0)
+	case ">":
+		return triOf(cmp > 0)
+	case ">=":
+		return triOf(cmp >= 0)
+	}
+	return triUnknown
+}
+
+type inNode struct {
+	expr   operand
+	list   []operand
+	negate bool
+}
+
+func (n inNode) eval(row map[string]interface{}) tri {
+	value := n.expr.value(row)
+	result := triFalse
+	for _, item := range n.list {
+		cmp, ok := compareValues(value, item.value(row))
+		if !ok {
+			result = triUnknown
+			continue
+		}
+		if cmp == 0 {
+			result = triTrue
+			break
+		}
+	}
+	if n.negate {
+		return notNode{constNode(result)}.eval(row)
+	}
+	return result
+}
+
+type isNullNode struct {
+	expr   operand
+	negate bool
+}
+
+func (n isNullNode) eval(row map[string]interface{}) tri {
+	return triOf((n.expr.value(row) == nil) != n.negate)
+}
+
+// truthNode is a bare boolean operand, such as a boolean column
+type truthNode struct{ expr operand }
+
+func (n truthNode) eval(row map[string]interface{}) tri {
+	switch v := n.expr.value(row).(type) {
+	case nil:
+		return triUnknown
+	case bool:
+		return triOf(v)
+	}
+	return triUnknown
+}
+
+type constNode tri
+
+func (n constNode) eval(map[string]interface{}) tri {
+	return tri(n)
+}
+
+// valueKind is what an operand compares as
+type valueKind int
+
+const (
+	kindNull valueKind = iota
+	kindNumber
+	kindBool
+	kindText
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "boolean"
+	case kindText:
+		return "text"
+	}
+	return "NULL"
+}
+
+// columnKind returns what values of a column of type oid compare as. Types other
+// than numbers and booleans compare as their text.
+func columnKind(oid uint32) valueKind {
+	switch oid {
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.OIDOID,
+		pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		return kindNumber
+	case pgtype.BoolOID:
+		return kindBool
+	}
+	return kindText
+}
+
+type operand interface {
+	value(row map[string]interface{}) interface{}
+	kind() valueKind
+}
+
+type columnOperand struct {
+	name string
+	k    valueKind
+}
+
+func (c columnOperand) value(row map[string]interface{}) interface{} {
+	return row[c.name]
+}
+
+func (c columnOperand) kind() valueKind {
+	return c.k
+}
+
+type literalOperand struct {
+	v interface{}
+	k valueKind
+}
+
+func (l literalOperand) value(map[string]interface{}) interface{} {
+	return l.v
+}
+
+func (l literalOperand) kind() valueKind {
+	return l.k
+}
+
+// checkComparable reports an error unless a and b are of the same kind, or either is NULL
+func checkComparable(a, b operand) error {
+	if a.kind() != kindNull && b.kind() != kindNull && a.kind() != b.kind() {
+		return fmt.Errorf("cannot compare %s with %s in predicate", a.kind(), b.kind())
+	}
+	return nil
+}
+
+// compareValues orders two values, reporting false when either is NULL or they
+// cannot be compared
+func compareValues(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			}
+			return 1, true
+		}
+	default:
+		if x, ok := toNumber(a); ok {
+			if y, ok := toNumber(b); ok {
+				return compareNumbers(x, y), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// toNumber returns the numeric text of v if it is a finite number. NaN and the
+// infinities do not order with other numbers as SQL does, and compare as unknown.
+func toNumber(v interface{}) (json.Number, bool) {
+	var n json.Number
+	switch x := v.(type) {
+	case json.Number:
+		n = x
+	case float64:
+		n = json.Number(strconv.FormatFloat(x, 'f', -1, 64))
+	default:
+		return "", false
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", false
+	}
+	return n, true
+}
+
+func compareNumbers(a, b json.Number) int {
+	if x, err := a.Int64(); err == nil {
+		if y, err := b.Int64(); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	x, _ := a.Float64()
+	y, _ := b.Float64()
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true,
+	"NULL": true, "TRUE": true, "FALSE": true,
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{tokenPunct, string(c)})
+			i++
+
+		case strings.ContainsRune("=<>!", c):
+			op := string(c)
+			if i+1 < len(s) {
+				if two := s[i : i+2]; two == "<=" || two == ">=" || two == "<>" || two == "!=" {
+					op = two
+				}
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected %q in predicate", op)
+			}
+			tokens = append(tokens, token{tokenOperator, op})
+			i += len(op)
+
+		case c == '\'':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated string in predicate")
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						sb.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteByte(s[i])
+				i++
+			}
+			tokens = append(tokens, token{tokenString, sb.String()})
+
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated identifier in predicate")
+			}
+			tokens = append(tokens, token{tokenIdent, s[i+1 : i+1+end]})
+			i += end + 2
+
+		case c == '-' || c == '.' || unicode.IsDigit(c):
+			start := i
+			i++
+			for i < len(s) && (unicode.IsDigit(rune(s[i])) || strings.ContainsRune(".eE", rune(s[i])) ||
+				(strings.ContainsRune("+-", rune(s[i])) && strings.ContainsRune("eE", rune(s[i-1])))) {
+				i++
+			}
+			number := s[start:i]
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q in predicate", number)
+			}
+			tokens = append(tokens, token{tokenNumber, number})
+
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(s) && (s[i] == '_' || unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i]))) {
+				i++
+			}
+			word := s[start:i]
+			if upper := strings.ToUpper(word); keywords[upper] {
+				tokens = append(tokens, token{tokenKeyword, upper})
+			} else {
+				// Unquoted identifiers fold to lower case, as in Postgres
+				tokens = append(tokens, token{tokenIdent, strings.ToLower(word)})
+			}
+
+		default:
+			return nil, fmt.Errorf("unexpected %q in predicate", c)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	// Kinds of the columns of the table, and the columns referred to
+	kinds   map[string]valueKind
+	columns map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) accept(kind tokenKind, text string) bool {
+	if tok := p.peek(); tok.kind == kind && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if !p.accept(kind, text) {
+		return fmt.Errorf("expected %q in predicate, got %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenKeyword, "OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenKeyword, "AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept(tokenKeyword, "NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	}
+	if p.accept(tokenPunct, "(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunct, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := p.peek(); {
+	case tok.kind == tokenOperator:
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkComparable(left, right); err != nil {
+			return nil, err
+		}
+		return compareNode{op: tok.text, left: left, right: right}, nil
+
+	case tok.kind == tokenKeyword && tok.text == "IS":
+		p.next()
+		negate := p.accept(tokenKeyword, "NOT")
+		if err := p.expect(tokenKeyword, "NULL"); err != nil {
+			return nil, err
+		}
+		return isNullNode{expr: left, negate: negate}, nil
+
+	case tok.kind == tokenKeyword && (tok.text == "IN" || tok.text == "NOT"):
+		p.next()
+		negate := tok.text == "NOT"
+		if negate {
+			if err := p.expect(tokenKeyword, "IN"); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(tokenPunct, "("); err != nil {
+			return nil, err
+		}
+		var list []operand
+		for {
+			item, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkComparable(left, item); err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+			if !p.accept(tokenPunct, ",") {
+				break
+			}
+		}
+		if err := p.expect(tokenPunct, ")"); err != nil {
+			return nil, err
+		}
+		return inNode{expr: left, list: list, negate: negate}, nil
+	}
+
+	if left.kind() != kindBool && left.kind() != kindNull {
+		return nil, fmt.Errorf("%s operand used as a condition in predicate", left.kind())
+	}
+	return truthNode{left}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenIdent:
+		kind, ok := p.kinds[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q in predicate", tok.text)
+		}
+		p.columns[tok.text] = true
+		return columnOperand{name: tok.text, k: kind}, nil
+	case tokenNumber:
+		return literalOperand{json.Number(tok.text), kindNumber}, nil
+	case tokenString:
+		return literalOperand{tok.text, kindText}, nil
+	case tokenKeyword:
+		switch tok.text {
+		case "NULL":
+			return literalOperand{nil, kindNull}, nil
+		case "TRUE":
+			return literalOperand{true, kindBool}, nil
+		case "FALSE":
+			return literalOperand{false, kindBool}, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of predicate")
+	}
+	return nil, fmt.Errorf("unexpected %q in predicate", tok.text)
+}
@@ -0,0 +1,187 @@
+package replication
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+var testRelation = &chat.RelationEvent{
+	Schema: "public",
+	Table:  "orders",
+	Columns: []*chat.RelationColumn{
+		{Name: "id", TypeOid: pgtype.Int8OID},
+		{Name: "status", TypeOid: pgtype.TextOID},
+		{Name: "code", TypeOid: pgtype.VarcharOID},
+		{Name: "price", TypeOid: pgtype.Float8OID},
+		{Name: "amount", TypeOid: pgtype.NumericOID},
+		{Name: "paid", TypeOid: pgtype.BoolOID},
+		{Name: "Note", TypeOid: pgtype.TextOID},
+	},
+}
+
+func testRow(values map[string]*chat.Value) *chat.Row {
+	row := &chat.Row{}
+	for name, value := range values {
+		row.Columns = append(row.Columns, &chat.Column{Name: name, Value: value})
+	}
+	return row
+}
+
+func intValue(v int64) *chat.Value {
+	return &chat.Value{Kind: &chat.Value_IntValue{IntValue: v}}
+}
+
+func floatValue(v float64) *chat.Value {
+	return &chat.Value{Kind: &chat.Value_FloatValue{FloatValue: v}}
+}
+
+func numericValue(v string) *chat.Value {
+	return &chat.Value{Kind: &chat.Value_NumericValue{NumericValue: v}}
+}
+
+func textValue(v string) *chat.Value {
+	return &chat.Value{Kind: &chat.Value_TextValue{TextValue: v}}
+}
+
+func boolValue(v bool) *chat.Value {
+	return &chat.Value{Kind: &chat.Value_BoolValue{BoolValue: v}}
+}
+
+func nullValue() *chat.Value {
+	return &chat.Value{Kind: &chat.Value_NullValue{}}
+}
+
+func TestParsePredicateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate string
+	}{
+		{"unknown column", "tenant_id = 42"},
+		{"case folded column", "Note = 'x'"},
+		{"number with text column", "code = 10"},
+		{"text with number column", "id = '10'"},
+		{"boolean with number", "paid = 1"},
+		{"columns of different kinds", "id = status"},
+		{"list item of different kind", "id IN (1, 'two')"},
+		{"text column as condition", "status"},
+		{"number as condition", "1 AND paid"},
+		{"unterminated string", "status = 'open"},
+		{"unterminated identifier", `"status = 'open'`},
+		{"invalid number", "id = 1.2.3"},
+		{"out of range number", "price < 1e999"},
+		{"unexpected operator", "id ! 1"},
+		{"trailing tokens", "id = 1 2"},
+		{"missing operand", "id ="},
+		{"missing parenthesis", "(id = 1"},
+		{"empty list", "id IN ()"},
+		{"IS without NULL", "status IS 'open'"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePredicate(tt.predicate, testRelation); err == nil {
+				t.Errorf("ParsePredicate(%q) succeeded, want an error", tt.predicate)
+			}
+		})
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	row := testRow(map[string]*chat.Value{
+		"id":     intValue(42),
+		"status": textValue("open"),
+		"code":   textValue("10"),
+		"price":  floatValue(9.5),
+		"amount": numericValue("1234.50"),
+		"paid":   boolValue(true),
+		"Note":   nullValue(),
+	})
+	tests := []struct {
+		name      string
+		predicate string
+		row       *chat.Row
+		want      bool
+	}{
+		{"integer equality", "id = 42", row, true},
+		{"integer inequality", "id <> 42", row, false},
+		{"integer and decimal", "id < 42.5", row, true},
+		{"float", "price >= 9.5", row, true},
+		{"numeric", "amount > 1234.5", row, false},
+		{"numeric equality across scales", "amount = 1234.5", row, true},
+		{"exponent", "amount < 1.3e3", row, true},
+		{"negative number", "id > -1", row, true},
+		{"text", "status = 'open'", row, true},
+		{"keywords are case insensitive", "status in ('open', 'pending')", row, true},
+		{"digits in text compare as text", "code < '9'", row, true},
+		{"quoted identifier", `"Note" IS NULL`, row, true},
+		{"unquoted identifiers fold", "STATUS = 'open'", row, true},
+		{"escaped quote", "status <> 'it''s'", row, true},
+		{"boolean column", "paid", row, true},
+		{"boolean comparison", "paid = FALSE", row, false},
+		{"IN", "id IN (1, 42)", row, true},
+		{"NOT IN", "id NOT IN (1, 2)", row, true},
+		{"NOT IN with NULL is unknown", "id NOT IN (1, NULL)", row, false},
+		{"IN with NULL and a match", "id IN (NULL, 42)", row, true},
+		{"IS NULL", "status IS NULL", row, false},
+		{"IS NOT NULL", "status IS NOT NULL", row, true},
+		{"comparison with NULL is unknown", "NOT (\"Note\" = 'x')", row, false},
+		{"NULL literal is unknown", "id = NULL", row, false},
+		{"missing column is NULL", "status = 'open'", testRow(nil), false},
+		{"missing column IS NULL", "status IS NULL", testRow(nil), true},
+		{"AND", "id = 42 AND status = 'closed'", row, false},
+		{"OR", "id = 1 OR status = 'open'", row, true},
+		{"unknown OR true", "\"Note\" = 'x' OR paid", row, true},
+		{"unknown AND false", "\"Note\" = 'x' AND NOT paid", row, false},
+		{"NOT binds tighter than AND", "NOT id = 1 AND paid", row, true},
+		{"AND binds tighter than OR", "id = 1 AND paid OR status = 'open'", row, true},
+		{"parentheses", "id = 1 AND (paid OR status = 'open')", row, false},
+		{"NaN is unknown", "price > 0",
+			testRow(map[string]*chat.Value{"price": floatValue(math.NaN())}), false},
+		{"NOT NaN is unknown", "NOT price > 0",
+			testRow(map[string]*chat.Value{"price": floatValue(math.NaN())}), false},
+		{"infinity is unknown", "price > 0",
+			testRow(map[string]*chat.Value{"price": floatValue(math.Inf(1))}), false},
+		{"numeric NaN is unknown", "NOT amount = 0",
+			testRow(map[string]*chat.Value{"amount": numericValue("NaN")}), false},
+		{"numeric infinity is unknown", "amount > 0",
+			testRow(map[string]*chat.Value{"amount": numericValue("Infinity")}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate, err := ParsePredicate(tt.predicate, testRelation)
+			if err != nil {
+				t.Fatalf("ParsePredicate(%q) failed: %v", tt.predicate, err)
+			}
+			if got := predicate.Match(rowValues(tt.row)); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.predicate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredicateCovers(t *testing.T) {
+	predicate, err := ParsePredicate("id = 1 OR status = 'open'", testRelation)
+	if err != nil {
+		t.Fatalf("ParsePredicate failed: %v", err)
+	}
+	tests := []struct {
+		name string
+		row  *chat.Row
+		want bool
+	}{
+		{"all columns", testRow(map[string]*chat.Value{"id": intValue(1), "status": nullValue()}), true},
+		{"key only", testRow(map[string]*chat.Value{"id": intValue(1)}), false},
+		{"no row", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := predicate.Covers(rowValues(tt.row)); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
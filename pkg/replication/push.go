@@ -0,0 +1,612 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/conflict"
+)
+
+const (
+	// RowOriginsTable records which replica last wrote a row through a push
+	RowOriginsTable = "syncer_row_origins"
+	// ConflictsTable holds conflicts parked for manual review
+	ConflictsTable = "syncer_conflicts"
+	// PushedChangesTable records the result of every change applied by id
+	PushedChangesTable = "syncer_pushed_changes"
+)
+
+var (
+	// ErrPushUnavailable is returned when a pushed change could not be applied for
+	// reasons of the source, such as a lost connection or a serialization failure.
+	// The change was not applied, and pushing it again may succeed.
+	ErrPushUnavailable = errors.New("change could not be applied now")
+
+	// errInvalidChange marks changes the source can never apply as pushed
+	errInvalidChange = errors.New("invalid change")
+)
+
+type rowOrigin struct {
+	Table   string `gorm:"column:table_name;primaryKey"`
+	RowKey  string `gorm:"type:jsonb;primaryKey"`
+	Origin  string `gorm:"not null"`
+	Version string `gorm:"not null"`
+}
+
+func (rowOrigin) TableName() string {
+	return RowOriginsTable
+}
+
+type parkedConflict struct {
+	ID             uint64 `gorm:"primaryKey"`
+	Table          string `gorm:"column:table_name;not null"`
+	RowKey         string `gorm:"type:jsonb;not null"`
+	Operation      string `gorm:"not null"`
+	Origin         string `gorm:"not null"`
+	BaseVersion    string
+	CurrentVersion string
+	Base           *string `gorm:"type:jsonb"`
+	Incoming       *string `gorm:"type:jsonb"`
+	Current        *string `gorm:"type:jsonb"`
+	ChangedAt      time.Time
+	DetectedAt     time.Time `gorm:"autoCreateTime"`
+	Resolved       bool      `gorm:"not null;default:false"`
+}
+
+func (parkedConflict) TableName() string {
+	return ConflictsTable
+}
+
+type pushedChange struct {
+	Origin     string `gorm:"primaryKey"`
+	ChangeID   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Status     chat.ChangeStatus
+	Conflict   bool
+	RowVersion string
+	Message    string
+	AppliedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (pushedChange) TableName() string {
+	return PushedChangesTable
+}
+
+// PushApplier applies changes pushed by replicas to the source. A change conflicts
+// when the row's version, made from the xmin of its current tuple, is no longer the
+// version the replica based the change on; such changes go to the resolver.
+//
+// Rows written inside subtransactions carry the subtransaction's id rather than
+// the one streamed, so changes to them always conflict.
+type PushApplier struct {
+	db           *gorm.DB
+	resolver     conflict.Resolver
+	sourceOrigin string
+	system       string
+	masks        *ColumnMasks
+}
+
+// NewPushApplier creates the applier's bookkeeping tables. sourceOrigin names the
+// origin of rows written directly on the source, and system is the system
+// identifier of the source. Columns masks hashes or redacts are never written, as
+// replicas only hold their masked values. Resolvers ordering writes by commit time
+// need track_commit_timestamp, without which every push would win.
+func NewPushApplier(db *gorm.DB, resolver conflict.Resolver, sourceOrigin, system string, masks *ColumnMasks) (*PushApplier, error) {
+	if conflict.UsesCommitTime(resolver) {
+		var enabled bool
+		if err := db.Raw("SELECT current_setting('track_commit_timestamp')::bool").Scan(&enabled).Error; err != nil {
+			return nil, fmt.Errorf("failed to read track_commit_timestamp: %w", err)
+		}
+		if !enabled {
+			return nil, errors.New("the conflict resolver orders writes by commit time, which needs track_commit_timestamp = on")
+		}
+	}
+	if err := db.AutoMigrate(&rowOrigin{}, &parkedConflict{}, &pushedChange{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate push tables: %w", err)
+	}
+	return &PushApplier{db: db, resolver: resolver, sourceOrigin: sourceOrigin, system: system, masks: masks}, nil
+}
+
+// Apply applies a pushed change in its own transaction. Changes with an id are
+// applied once, the result is recorded with them. Changes the source rejects, such
+// as invalid ones or ones violating a constraint, fail; other errors are returned
+// wrapping ErrPushUnavailable.
+func (a *PushApplier) Apply(ctx context.Context, push *chat.PushChangesRequest) (*chat.ChangeResult, error) {
+	var result *chat.ChangeResult
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if push.GetChangeId() == 0 {
+			var err error
+			result, err = a.apply(ctx, tx, push)
+			return err
+		}
+
+		var pushed pushedChange
+		err := tx.Where("origin = ? AND change_id = ?", push.GetOrigin(), push.GetChangeId()).Take(&pushed).Error
+		if err == nil {
+			result = &chat.ChangeResult{
+				Status:     pushed.Status,
+				Conflict:   pushed.Conflict,
+				RowVersion: pushed.RowVersion,
+				Message:    pushed.Message,
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to look up change %d: %w", push.GetChangeId(), err)
+		}
+
+		if result, err = a.apply(ctx, tx, push); err != nil {
+			return err
+		}
+		err = tx.Create(&pushedChange{
+			Origin:     push.GetOrigin(),
+			ChangeID:   push.GetChangeId(),
+			Status:     result.Status,
+			Conflict:   result.Conflict,
+			RowVersion: result.RowVersion,
+			Message:    result.Message,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to record change %d: %w", push.GetChangeId(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		if !rejected(err) {
+			return nil, fmt.Errorf("%w: %v", ErrPushUnavailable, err)
+		}
+		return &chat.ChangeResult{Status: chat.ChangeStatus_CHANGE_STATUS_FAILED, Message: err.Error()}, nil
+	}
+	return result, nil
+}
+
+// rejected reports whether err rejects the change itself, so applying it again
+// would fail the same way
+func rejected(err error) bool {
+	if errors.Is(err, errInvalidChange) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(pgErr.Code) == 5 {
+		switch pgErr.Code[:2] {
+		// Data exceptions, integrity constraint violations, syntax errors and
+		// undefined objects, check option violations and raised exceptions
+		case "22", "23", "42", "44", "P0":
+			return true
+		}
+	}
+	return false
+}
+
+// currentRow is a row as it is on the source
+type currentRow struct {
+	Version     string
+	Data        string
+	CommittedAt *time.Time
+}
+
+func (a *PushApplier) apply(ctx context.Context, tx *gorm.DB, push *chat.PushChangesRequest) (*chat.ChangeResult, error) {
+	change := push.GetChange()
+	op := change.GetOperation()
+	if op != chat.Operation_OPERATION_INSERT && op != chat.Operation_OPERATION_UPDATE && op != chat.Operation_OPERATION_DELETE {
+		return nil, fmt.Errorf("%w: unsupported operation %s", errInvalidChange, op)
+	}
+
+	schema, name, ok := strings.Cut(change.GetTable(), ".")
+	if !ok {
+		return nil, fmt.Errorf("%w: table %q is not schema-qualified", errInvalidChange, change.GetTable())
+	}
+	table := pgx.Identifier{schema, name}
+	types, err := columnTypes(tx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if column, ok := a.masks.maskedKey(change.GetTable(), change.GetKeyColumns()); ok {
+		return nil, fmt.Errorf("%w: key column %s is masked", errInvalidChange, column)
+	}
+	base, incoming := pushedRow(change.GetOldData()), pushedRow(change.GetData())
+	a.masks.unmask(change.GetTable(), base)
+	dropped := a.masks.unmask(change.GetTable(), incoming)
+	keyRow := base
+	if keyRow == nil {
+		keyRow = incoming
+	}
+	key, err := pushedKey(change.GetKeyColumns(), keyRow)
+	if err != nil {
+		return nil, err
+	}
+	rowKey, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args, err := keyCondition(types, key)
+	if err != nil {
+		return nil, err
+	}
+	current, err := lockRows(tx, a.system, table, where, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) > 1 {
+		return nil, fmt.Errorf("%w: key %s matches %d rows", errInvalidChange, rowKey, len(current))
+	}
+
+	result := &chat.ChangeResult{}
+	switch {
+	case len(current) == 0:
+		// A row deleted on both sides needs nothing more
+		if op == chat.Operation_OPERATION_DELETE {
+			result.Status = chat.ChangeStatus_CHANGE_STATUS_APPLIED
+			return result, nil
+		}
+		result.Conflict = op == chat.Operation_OPERATION_UPDATE
+	case op == chat.Operation_OPERATION_INSERT:
+		result.Conflict = true
+	default:
+		result.Conflict = current[0].Version != push.GetBaseVersion()
+	}
+
+	resolution := conflict.Resolution{Action: conflict.ApplyIncoming}
+	var found *currentRow
+	if len(current) == 1 {
+		found = &current[0]
+	}
+	if result.Conflict {
+		c := &conflict.Conflict{
+			Table:         change.GetTable(),
+			Key:           key,
+			Operation:     op,
+			Origin:        push.GetOrigin(),
+			ChangedAt:     push.GetChangedAt().AsTime(),
+			Base:          base,
+			Incoming:      incoming,
+			CurrentOrigin: a.sourceOrigin,
+		}
+		if found != nil {
+			if c.Current, err = decodeRow([]byte(found.Data)); err != nil {
+				return nil, err
+			}
+			if found.CommittedAt != nil {
+				c.CurrentTime = *found.CommittedAt
+			}
+			if c.CurrentOrigin, err = a.rowOrigin(tx, change.GetTable(), string(rowKey), found.Version); err != nil {
+				return nil, err
+			}
+		}
+		if resolution, err = a.resolver.Resolve(ctx, c); err != nil {
+			return nil, fmt.Errorf("failed to resolve conflict on %s %s: %w", change.GetTable(), rowKey, err)
+		}
+	}
+
+	switch resolution.Action {
+	case conflict.ApplyIncoming:
+		result.Status = chat.ChangeStatus_CHANGE_STATUS_APPLIED
+		result.Message = unmaskedMessage(dropped)
+		result.RowVersion, err = a.write(tx, table, types, where, args, op, incoming, found != nil, push.GetOrigin(), change.GetKeyColumns())
+	case conflict.ApplyMerged:
+		result.Status = chat.ChangeStatus_CHANGE_STATUS_MERGED
+		result.Message = unmaskedMessage(dropped)
+		a.masks.unmask(change.GetTable(), resolution.Row)
+		result.RowVersion, err = a.write(tx, table, types, where, args, chat.Operation_OPERATION_UPDATE, resolution.Row, found != nil, push.GetOrigin(), change.GetKeyColumns())
+	case conflict.KeepCurrent:
+		result.Status = chat.ChangeStatus_CHANGE_STATUS_REJECTED
+		result.Message = "the row changed on the source"
+		if found != nil {
+			result.RowVersion = found.Version
+		}
+	case conflict.Park:
+		result.Status = chat.ChangeStatus_CHANGE_STATUS_PARKED
+		err = a.park(tx, push, string(rowKey), found)
+	default:
+		err = fmt.Errorf("unknown conflict resolution %d", resolution.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rowOrigin returns the origin that wrote version of a row, the source unless a push did
+func (a *PushApplier) rowOrigin(tx *gorm.DB, table, rowKey, version string) (string, error) {
+	var origin rowOrigin
+	err := tx.Where("table_name = ? AND row_key = ?", table, rowKey).Take(&origin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && origin.Version != version) {
+		return a.sourceOrigin, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load row origin: %w", err)
+	}
+	return origin.Origin, nil
+}
+
+// write applies row to the row matched by where, inserting it if the row does not
+// exist, and records origin as its writer. It returns the new row version.
+func (a *PushApplier) write(tx *gorm.DB, table pgx.Identifier, types map[string]string, where string, args []interface{},
+	op chat.Operation, row map[string]interface{}, exists bool, origin string, keyColumns []string) (string, error) {
+	if op == chat.Operation_OPERATION_DELETE {
+		return "", deleteRows(tx, table, where, args)
+	}
+	version, err := writeRow(tx, a.system, table, types, where, args, row, exists)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := pushedKey(keyColumns, row)
+	if err != nil {
+		return "", err
+	}
+	rowKey, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "table_name"}, {Name: "row_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"origin", "version"}),
+	}).Create(&rowOrigin{Table: table[0] + "." + table[1], RowKey: string(rowKey), Origin: origin, Version: version}).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to record row origin: %w", err)
+	}
+	return version, nil
+}
+
+// park records a conflict for manual review
+func (a *PushApplier) park(tx *gorm.DB, push *chat.PushChangesRequest, rowKey string, current *currentRow) error {
+	change := push.GetChange()
+	parked := &parkedConflict{
+		Table:       change.GetTable(),
+		RowKey:      rowKey,
+		Operation:   change.GetOperation().String(),
+		Origin:      push.GetOrigin(),
+		BaseVersion: push.GetBaseVersion(),
+		ChangedAt:   push.GetChangedAt().AsTime(),
+	}
+	var err error
+	if parked.Base, err = jsonRow(pushedRow(change.GetOldData())); err != nil {
+		return err
+	}
+	if parked.Incoming, err = jsonRow(pushedRow(change.GetData())); err != nil {
+		return err
+	}
+	if current != nil {
+		parked.CurrentVersion = current.Version
+		parked.Current = &current.Data
+	}
+	if err := tx.Create(parked).Error; err != nil {
+		return fmt.Errorf("failed to park conflict: %w", err)
+	}
+	return nil
+}
+
+// lockRows locks the rows matched by where, returning them with their versions on
+// system
+func lockRows(tx *gorm.DB, system string, table pgx.Identifier, where string, args []interface{}) ([]currentRow, error) {
+	var rows []currentRow
+	err := tx.Raw(fmt.Sprintf(`
+		SELECT %s AS version, to_jsonb(t)::text AS data,
+			CASE WHEN current_setting('track_commit_timestamp')::bool THEN pg_xact_commit_timestamp(t.xmin) END AS committed_at
+		FROM %s t WHERE %s FOR UPDATE`, versionSQL("t.xmin"), table.Sanitize(), where), append([]interface{}{system}, args...)...).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table.Sanitize(), err)
+	}
+	return rows, nil
+}
+
+// writeRow updates the row matched by where with the values of row, or inserts row
+// unless exists. It returns the new row version on system.
+func writeRow(tx *gorm.DB, system string, table pgx.Identifier, types map[string]string, where string, args []interface{},
+	row map[string]interface{}, exists bool) (string, error) {
+	names := make([]string, 0, len(row))
+	for column := range row {
+		if _, ok := types[column]; !ok {
+			return "", fmt.Errorf("%w: unknown column %s in %s", errInvalidChange, column, table.Sanitize())
+		}
+		names = append(names, column)
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("%w: no columns to write to %s", errInvalidChange, table.Sanitize())
+	}
+	sort.Strings(names)
+
+	var sql strings.Builder
+	values := make([]interface{}, 0, len(names)+len(args))
+	if exists {
+		fmt.Fprintf(&sql, "UPDATE %s SET ", table.Sanitize())
+		for i, column := range names {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			fmt.Fprintf(&sql, "%s = CAST(? AS %s)", pgx.Identifier{column}.Sanitize(), types[column])
+			values = append(values, row[column])
+		}
+		fmt.Fprintf(&sql, " WHERE %s", where)
+		values = append(values, args...)
+	} else {
+		columns := make([]string, len(names))
+		placeholders := make([]string, len(names))
+		for i, column := range names {
+			columns[i] = pgx.Identifier{column}.Sanitize()
+			placeholders[i] = fmt.Sprintf("CAST(? AS %s)", types[column])
+			values = append(values, row[column])
+		}
+		fmt.Fprintf(&sql, "INSERT INTO %s (%s) VALUES (%s)", table.Sanitize(),
+			strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	}
+	sql.WriteString(" RETURNING " + versionSQL("xmin"))
+	values = append(values, system)
+
+	var versions []string
+	if err := tx.Raw(sql.String(), values...).Scan(&versions).Error; err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", table.Sanitize(), err)
+	}
+	if len(versions) != 1 {
+		return "", fmt.Errorf("wrote %d rows of %s", len(versions), table.Sanitize())
+	}
+	return versions[0], nil
+}
+
+// deleteRows deletes the rows matched by where
+func deleteRows(tx *gorm.DB, table pgx.Identifier, where string, args []interface{}) error {
+	if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table.Sanitize(), where), args...).Error; err != nil {
+		return fmt.Errorf("failed to delete from %s: %w", table.Sanitize(), err)
+	}
+	return nil
+}
+
+// columnTypes maps the columns of table to their SQL types
+func columnTypes(tx *gorm.DB, table pgx.Identifier) (map[string]string, error) {
+	rows, err := tx.Raw(`
+		SELECT attname, format_type(atttypid, atttypmod)
+		FROM pg_attribute
+		WHERE attrelid = CAST(? AS regclass) AND attnum > 0 AND NOT attisdropped`, table.Sanitize()).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe %s: %w", table.Sanitize(), err)
+	}
+	defer rows.Close()
+
+	types := make(map[string]string)
+	for rows.Next() {
+		var name, typeName string
+		if err := rows.Scan(&name, &typeName); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		types[name] = typeName
+	}
+	return types, rows.Err()
+}
+
+// keyCondition matches the row with key
+func keyCondition(types map[string]string, key map[string]interface{}) (string, []interface{}, error) {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conditions := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		typeName, ok := types[name]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown key column %s", errInvalidChange, name)
+		}
+		conditions[i] = fmt.Sprintf("%s = CAST(? AS %s)", pgx.Identifier{name}.Sanitize(), typeName)
+		args[i] = key[name]
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// pushedKey picks the key columns out of row
+func pushedKey(keyColumns []string, row map[string]interface{}) (map[string]interface{}, error) {
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("%w: change has no key columns", errInvalidChange)
+	}
+	key := make(map[string]interface{}, len(keyColumns))
+	for _, name := range keyColumns {
+		value, ok := row[name]
+		if !ok || value == nil {
+			return nil, fmt.Errorf("%w: change is missing key column %s", errInvalidChange, name)
+		}
+		key[name] = value
+	}
+	return key, nil
+}
+
+// pushedRow converts a pushed row to values in text form, nil for NULL
+func pushedRow(row *chat.Row) map[string]interface{} {
+	if row == nil {
+		return nil
+	}
+	values := make(map[string]interface{}, len(row.Columns))
+	for _, column := range row.Columns {
+		if text, ok := valueText(column.Value); ok {
+			values[column.Name] = text
+		} else {
+			values[column.Name] = nil
+		}
+	}
+	return values
+}
+
+// valueText returns the text form of a value, which Postgres casts back to the
+// column type, and false for NULL
+func valueText(value *chat.Value) (string, bool) {
+	switch v := value.GetKind().(type) {
+	case *chat.Value_IntValue:
+		return strconv.FormatInt(v.IntValue, 10), true
+	case *chat.Value_FloatValue:
+		return strconv.FormatFloat(v.FloatValue, 'g', -1, 64), true
+	case *chat.Value_TextValue:
+		return v.TextValue, true
+	case *chat.Value_BoolValue:
+		return strconv.FormatBool(v.BoolValue), true
+	case *chat.Value_ByteaValue:
+		return `\x` + hex.EncodeToString(v.ByteaValue), true
+	case *chat.Value_TimestampValue:
+		return v.TimestampValue.AsTime().Format(time.RFC3339Nano), true
+	case *chat.Value_NumericValue:
+		return v.NumericValue, true
+	case *chat.Value_JsonValue:
+		return v.JsonValue, true
+	}
+	return "", false
+}
+
+// decodeRow converts a row in to_jsonb form to values in text form. Nested JSON
+// is re-encoded compactly, as replicas encode it.
+func decodeRow(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode row: %w", err)
+	}
+
+	row := make(map[string]interface{}, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			row[name] = nil
+		case string:
+			row[name] = v
+		case json.Number:
+			row[name] = v.String()
+		case bool:
+			row[name] = strconv.FormatBool(v)
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			row[name] = string(encoded)
+		}
+	}
+	return row, nil
+}
+
+// jsonRow encodes a row for the conflicts table
+func jsonRow(row map[string]interface{}) (*string, error) {
+	if row == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	s := string(encoded)
+	return &s, nil
+}
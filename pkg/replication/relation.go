@@ -0,0 +1,171 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+var replicaIdentities = map[uint8]chat.ReplicaIdentity{
+	'd': chat.ReplicaIdentity_REPLICA_IDENTITY_DEFAULT,
+	'n': chat.ReplicaIdentity_REPLICA_IDENTITY_NOTHING,
+	'f': chat.ReplicaIdentity_REPLICA_IDENTITY_FULL,
+	'i': chat.ReplicaIdentity_REPLICA_IDENTITY_INDEX,
+}
+
+// keyIndexSQL joins the index whose columns key the rows of table c: the primary
+// key, else the replica identity index. Without either, a table with FULL replica
+// identity is keyed by all of its columns.
+const keyIndexSQL = `
+	LEFT JOIN LATERAL (
+		SELECT indkey FROM pg_index
+		WHERE indrelid = c.oid AND (indisprimary OR indisreplident)
+		ORDER BY indisprimary DESC LIMIT 1
+	) k ON true`
+
+// keySQL is the SQL for whether column a is one of the key columns of table c
+const keySQL = "CASE WHEN k.indkey IS NULL THEN c.relreplident = 'f' ELSE a.attnum = ANY(k.indkey) END"
+
+// attribute is the catalog information on a column that pgoutput leaves out
+type attribute struct {
+	typeName string
+	nullable bool
+	key      bool
+}
+
+// describeRelation builds the relation of a pgoutput Relation message, completed
+// from the catalog. The catalog reflects the current shape of the table, which may
+// already be ahead of the stream.
+func describeRelation(ctx context.Context, q querier, masks *ColumnMasks, rel *pglogrepl.RelationMessage) (*chat.RelationEvent, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, `+keySQL+`
+		FROM pg_class c
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped`+keyIndexSQL+`
+		WHERE c.oid = $1`, rel.RelationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", qualifiedName(rel.Namespace, rel.RelationName), err)
+	}
+	defer rows.Close()
+
+	attributes := make(map[string]attribute)
+	for rows.Next() {
+		var name string
+		var attr attribute
+		if err := rows.Scan(&name, &attr.typeName, &attr.nullable, &attr.key); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		attributes[name] = attr
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	relation := &chat.RelationEvent{
+		Id:              rel.RelationID,
+		Schema:          rel.Namespace,
+		Table:           rel.RelationName,
+		ReplicaIdentity: replicaIdentities[rel.ReplicaIdentity],
+	}
+	table := qualifiedName(rel.Namespace, rel.RelationName)
+	for _, column := range rel.Columns {
+		// Columns dropped since are unknown to the catalog, and may be NULL
+		attr, ok := attributes[column.Name]
+		if !ok {
+			attr.nullable = true
+		}
+		relation.Columns = appendRelationColumn(relation.Columns, masks, table, &chat.RelationColumn{
+			Name:         column.Name,
+			TypeOid:      column.DataType,
+			TypeModifier: column.TypeModifier,
+			TypeName:     attr.typeName,
+			Nullable:     attr.nullable,
+			Key:          attr.key,
+		})
+	}
+	return relation, nil
+}
+
+// describeTable reads the relation of a table from the catalog
+func describeTable(ctx context.Context, q querier, masks *ColumnMasks, table pgx.Identifier) (*chat.RelationEvent, error) {
+	rows, err := q.Query(ctx, `
+		SELECT c.oid, c.relreplident::text, a.attname, a.atttypid, a.atttypmod,
+			format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, `+keySQL+`
+		FROM pg_class c
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped`+keyIndexSQL+`
+		WHERE c.oid = $1::regclass
+		ORDER BY a.attnum`, table.Sanitize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe %s: %w", table.Sanitize(), err)
+	}
+	defer rows.Close()
+
+	name := qualifiedName(table[0], table[1])
+	relation := &chat.RelationEvent{Schema: table[0], Table: table[1]}
+	for rows.Next() {
+		var replicaIdentity string
+		column := &chat.RelationColumn{}
+		err := rows.Scan(&relation.Id, &replicaIdentity, &column.Name, &column.TypeOid, &column.TypeModifier,
+			&column.TypeName, &column.Nullable, &column.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		if replicaIdentity != "" {
+			relation.ReplicaIdentity = replicaIdentities[replicaIdentity[0]]
+		}
+		relation.Columns = appendRelationColumn(relation.Columns, masks, name, column)
+	}
+	return relation, rows.Err()
+}
+
+// appendRelationColumn appends column as the masks let it leave the process
+func appendRelationColumn(columns []*chat.RelationColumn, masks *ColumnMasks, table string, column *chat.RelationColumn) []*chat.RelationColumn {
+	if masks.redacted(table, column.Name) {
+		return columns
+	}
+	if masks.hashed(table, column.Name) {
+		column.TypeOid = pgtype.TextOID
+		column.TypeModifier = -1
+		column.TypeName = "text"
+	}
+	return append(columns, column)
+}
+
+// keyColumnNames returns the names of the key columns of relation
+func keyColumnNames(relation *chat.RelationEvent) []string {
+	var names []string
+	for _, column := range relation.GetColumns() {
+		if column.Key {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+// newRelationEvent wraps relation in a RELATION event
+func newRelationEvent(relation *chat.RelationEvent) *chat.DataChangeEvent {
+	return &chat.DataChangeEvent{
+		Operation:  chat.Operation_OPERATION_RELATION,
+		Table:      qualifiedName(relation.Schema, relation.Table),
+		Relation:   relation,
+		RelationId: relation.Id,
+	}
+}
+
+// projectRelation leaves only the selected and key columns in a copy of relation
+func projectRelation(relation *chat.RelationEvent, projection map[string]bool) *chat.RelationEvent {
+	projected := proto.Clone(relation).(*chat.RelationEvent)
+	columns := projected.Columns[:0]
+	for _, column := range projected.Columns {
+		if projection[column.Name] || column.Key {
+			columns = append(columns, column)
+		}
+	}
+	projected.Columns = columns
+	return projected
+}
@@ -0,0 +1,88 @@
+package replication
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// encodeColumn converts a column value in Postgres text form, nil for NULL, to a
+// typed column. Redacted columns are returned as nil.
+func encodeColumn(typeMap *pgtype.Map, masks *ColumnMasks, table, name string, oid uint32, text []byte) (*chat.Column, error) {
+	if masks.redacted(table, name) {
+		return nil, nil
+	}
+	if text != nil && masks.hashed(table, name) {
+		return &chat.Column{
+			Name:    name,
+			TypeOid: pgtype.TextOID,
+			Value:   &chat.Value{Kind: &chat.Value_TextValue{TextValue: masks.hash(text)}},
+		}, nil
+	}
+
+	value, err := encodeValue(typeMap, oid, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode column %s.%s: %w", table, name, err)
+	}
+	return &chat.Column{Name: name, TypeOid: oid, Value: value}, nil
+}
+
+func encodeValue(typeMap *pgtype.Map, oid uint32, text []byte) (*chat.Value, error) {
+	if text == nil {
+		return &chat.Value{Kind: &chat.Value_NullValue{}}, nil
+	}
+
+	switch oid {
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.OIDOID:
+		v, err := strconv.ParseInt(string(text), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &chat.Value{Kind: &chat.Value_IntValue{IntValue: v}}, nil
+
+	case pgtype.Float4OID, pgtype.Float8OID:
+		v, err := strconv.ParseFloat(string(text), 64)
+		if err != nil {
+			return nil, err
+		}
+		return &chat.Value{Kind: &chat.Value_FloatValue{FloatValue: v}}, nil
+
+	case pgtype.BoolOID:
+		v, err := strconv.ParseBool(string(text))
+		if err != nil {
+			return nil, err
+		}
+		return &chat.Value{Kind: &chat.Value_BoolValue{BoolValue: v}}, nil
+
+	case pgtype.ByteaOID:
+		var v []byte
+		if err := typeMap.Scan(oid, pgtype.TextFormatCode, text, &v); err != nil {
+			return nil, err
+		}
+		return &chat.Value{Kind: &chat.Value_ByteaValue{ByteaValue: v}}, nil
+
+	case pgtype.TimestampOID, pgtype.TimestamptzOID, pgtype.DateOID:
+		dt, _ := typeMap.TypeForOID(oid)
+		v, err := dt.Codec.DecodeValue(typeMap, oid, pgtype.TextFormatCode, text)
+		if err != nil {
+			return nil, err
+		}
+		// Infinite timestamps have no Timestamp form
+		if t, ok := v.(time.Time); ok {
+			return &chat.Value{Kind: &chat.Value_TimestampValue{TimestampValue: timestamppb.New(t)}}, nil
+		}
+
+	case pgtype.NumericOID:
+		return &chat.Value{Kind: &chat.Value_NumericValue{NumericValue: string(text)}}, nil
+
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		return &chat.Value{Kind: &chat.Value_JsonValue{JsonValue: string(text)}}, nil
+	}
+
+	return &chat.Value{Kind: &chat.Value_TextValue{TextValue: string(text)}}, nil
+}
@@ -0,0 +1,254 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// Snapshot sends every row of the published tables passing the filter as INSERT
+// events, framed as a single transaction, and returns the position to resume the
+// change stream from.
+//
+// The rows are read in the snapshot exported by a temporary replication slot, so
+// resuming from the returned position continues with exactly the transactions that
+// committed after the snapshot.
+func (r *PostgresReplicator) Snapshot(ctx context.Context, tables *TableFilter, send func(*chat.DataChangeEvent) error) (Position, error) {
+	// The exported snapshot lives as long as this connection stays open and idle
+	replConn, err := connect(ctx, r.cfg)
+	if err != nil {
+		return Position{}, err
+	}
+	defer replConn.Close(context.Background())
+
+	slot := fmt.Sprintf("%s_snapshot_%d", r.cfg.Replication.Slot, time.Now().UnixNano())
+	result, err := pglogrepl.CreateReplicationSlot(ctx, replConn, slot, outputPlugin, pglogrepl.CreateReplicationSlotOptions{
+		Temporary:      true,
+		SnapshotAction: "EXPORT_SNAPSHOT",
+		Mode:           pglogrepl.LogicalReplication,
+	})
+	if err != nil {
+		return Position{}, fmt.Errorf("failed to create snapshot slot: %w", err)
+	}
+	consistentPoint, err := pglogrepl.ParseLSN(result.ConsistentPoint)
+	if err != nil {
+		return Position{}, fmt.Errorf("failed to parse consistent point: %w", err)
+	}
+
+	conn, err := pgx.Connect(ctx, r.cfg.GetPostgresDSN())
+	if err != nil {
+		return Position{}, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return Position{}, fmt.Errorf("failed to begin snapshot transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET TRANSACTION SNAPSHOT %s", quoteLiteral(result.SnapshotName))); err != nil {
+		return Position{}, fmt.Errorf("failed to import snapshot: %w", err)
+	}
+
+	published, err := r.publishedTables(ctx, tx)
+	if err != nil {
+		return Position{}, err
+	}
+
+	s := &snapshot{
+		send:            send,
+		system:          r.system,
+		masks:           r.masks,
+		typeMap:         conn.TypeMap(),
+		consistentPoint: consistentPoint,
+		timestamp:       timestamppb.Now(),
+	}
+	if err := s.emit(chat.Operation_OPERATION_BEGIN, 0, &chat.DataChangeEvent{}); err != nil {
+		return Position{}, err
+	}
+	copied := 0
+	for _, table := range published {
+		if !tables.Match(qualifiedName(table[0], table[1])) {
+			continue
+		}
+		if err := s.copyTable(ctx, tx, table); err != nil {
+			return Position{}, err
+		}
+		copied++
+	}
+	if err := s.emit(chat.Operation_OPERATION_COMMIT, math.MaxUint32, &chat.DataChangeEvent{}); err != nil {
+		return Position{}, err
+	}
+
+	log.Printf("Sent snapshot of %d tables with %d rows at %s", copied, s.rows, consistentPoint)
+	return s.position(math.MaxUint32), nil
+}
+
+// PublishedTables returns the schema-qualified names of the tables in the publication
+func (r *PostgresReplicator) PublishedTables(ctx context.Context) ([]string, error) {
+	conn, err := pgx.Connect(ctx, r.cfg.GetPostgresDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	published, err := r.publishedTables(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(published))
+	for _, table := range published {
+		names = append(names, qualifiedName(table[0], table[1]))
+	}
+	return names, nil
+}
+
+// DescribeTables returns the relations of the published tables keyed by their
+// schema-qualified names, masked as they are streamed
+func (r *PostgresReplicator) DescribeTables(ctx context.Context) (map[string]*chat.RelationEvent, error) {
+	conn, err := pgx.Connect(ctx, r.cfg.GetPostgresDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	published, err := r.publishedTables(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	relations := make(map[string]*chat.RelationEvent, len(published))
+	for _, table := range published {
+		relation, err := describeTable(ctx, conn, r.masks, table)
+		if err != nil {
+			return nil, err
+		}
+		relations[qualifiedName(table[0], table[1])] = relation
+	}
+	return relations, nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// publishedTables returns the tables in the publication
+func (r *PostgresReplicator) publishedTables(ctx context.Context, q querier) ([]pgx.Identifier, error) {
+	rows, err := q.Query(ctx,
+		"SELECT schemaname, tablename FROM pg_publication_tables WHERE pubname = $1 ORDER BY schemaname, tablename",
+		r.cfg.Replication.Publication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []pgx.Identifier
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return nil, fmt.Errorf("failed to scan published table: %w", err)
+		}
+		if internalTable(table) {
+			continue
+		}
+		tables = append(tables, pgx.Identifier{schema, table})
+	}
+	return tables, rows.Err()
+}
+
+// snapshot holds the state of a snapshot being sent
+type snapshot struct {
+	send            func(*chat.DataChangeEvent) error
+	system          string
+	masks           *ColumnMasks
+	typeMap         *pgtype.Map
+	consistentPoint pglogrepl.LSN
+	timestamp       *timestamppb.Timestamp
+	// Sequence number of the last event and the number of rows sent
+	seq  uint32
+	rows uint32
+}
+
+// position places the snapshot events just before the consistent point, since
+// transactions committing at or after it are not part of the snapshot
+func (s *snapshot) position(seq uint32) Position {
+	return Position{LSN: s.consistentPoint - 1, Seq: seq}
+}
+
+func (s *snapshot) copyTable(ctx context.Context, tx pgx.Tx, table pgx.Identifier) error {
+	name := qualifiedName(table[0], table[1])
+	relation, err := describeTable(ctx, tx, s.masks, table)
+	if err != nil {
+		return err
+	}
+	s.seq++
+	if err := s.emit(chat.Operation_OPERATION_RELATION, s.seq, newRelationEvent(relation)); err != nil {
+		return err
+	}
+	keyColumns := keyColumnNames(relation)
+
+	// The simple protocol returns every value in the text form the change stream uses.
+	// xmin, the transaction that last wrote a row, makes its version.
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT %s, * FROM %s", xidSQL("xmin"), table.Sanitize()), pgx.QueryExecModeSimpleProtocol)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table.Sanitize(), err)
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()[1:]
+	for rows.Next() {
+		version, values := s.system+":"+string(rows.RawValues()[0]), rows.RawValues()[1:]
+		row := &chat.Row{Columns: make([]*chat.Column, 0, len(fields))}
+		for i, field := range fields {
+			column, err := encodeColumn(s.typeMap, s.masks, name, field.Name, field.DataTypeOID, values[i])
+			if err != nil {
+				return err
+			}
+			if column != nil {
+				row.Columns = append(row.Columns, column)
+			}
+		}
+
+		s.seq++
+		s.rows++
+		event := &chat.DataChangeEvent{
+			Table:      name,
+			Data:       row,
+			KeyColumns: keyColumns,
+			RelationId: relation.Id,
+			RowVersion: version,
+		}
+		if err := s.emit(chat.Operation_OPERATION_INSERT, s.seq, event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *snapshot) emit(op chat.Operation, seq uint32, event *chat.DataChangeEvent) error {
+	event.Operation = op
+	event.Timestamp = s.timestamp
+	event.Position = s.position(seq).String()
+	if op == chat.Operation_OPERATION_BEGIN || op == chat.Operation_OPERATION_COMMIT {
+		event.Transaction = &chat.Transaction{
+			CommitLsn:       s.consistentPoint.String(),
+			CommitTimestamp: s.timestamp,
+			Snapshot:        true,
+		}
+		if op == chat.Operation_OPERATION_COMMIT {
+			event.Transaction.RowCount = s.rows
+		}
+	}
+	return s.send(event)
+}
@@ -0,0 +1,139 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// errRejected rolls back a batch of mutations one of which did not apply
+var errRejected = errors.New("mutation rejected")
+
+// SubmitMutations applies mutations to published tables in one transaction, in
+// order. The first mutation that does not apply rolls back the transaction, and the
+// ones after it are not attempted. system is the system identifier of the source,
+// which row versions carry. Columns masks hashes or redacts are not written, and
+// mutations keyed by them fail.
+func SubmitMutations(ctx context.Context, db *gorm.DB, system string, masks *ColumnMasks, published []string, mutations []*chat.Mutation) *chat.SubmitChangesResponse {
+	resp := &chat.SubmitChangesResponse{Results: make([]*chat.MutationResult, len(mutations))}
+	for i := range resp.Results {
+		resp.Results[i] = &chat.MutationResult{Status: chat.MutationStatus_MUTATION_STATUS_ABORTED}
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		types := make(map[string]map[string]string)
+		for i, mutation := range mutations {
+			result := submitMutation(tx, system, masks, published, types, mutation)
+			resp.Results[i] = result
+			if result.Status != chat.MutationStatus_MUTATION_STATUS_APPLIED {
+				return errRejected
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		resp.Committed = true
+		return resp
+	}
+
+	for _, result := range resp.Results {
+		if result.Status == chat.MutationStatus_MUTATION_STATUS_APPLIED {
+			result.Status = chat.MutationStatus_MUTATION_STATUS_ABORTED
+			result.RowVersion = ""
+		}
+	}
+	// The commit itself failed
+	if !errors.Is(err, errRejected) {
+		for _, result := range resp.Results {
+			result.Message = err.Error()
+		}
+	}
+	return resp
+}
+
+// submitMutation applies a single mutation. types caches the column types of the
+// tables written so far.
+func submitMutation(tx *gorm.DB, system string, masks *ColumnMasks, published []string, types map[string]map[string]string, mutation *chat.Mutation) *chat.MutationResult {
+	failed := func(format string, args ...interface{}) *chat.MutationResult {
+		return &chat.MutationResult{Status: chat.MutationStatus_MUTATION_STATUS_FAILED, Message: fmt.Sprintf(format, args...)}
+	}
+
+	name, err := resolveTable(mutation.GetTable(), nil, published)
+	if err != nil {
+		return failed("%v", err)
+	}
+	schema, relname, _ := strings.Cut(name, ".")
+	table := pgx.Identifier{schema, relname}
+	if types[name] == nil {
+		if types[name], err = columnTypes(tx, table); err != nil {
+			return failed("%v", err)
+		}
+	}
+
+	data := pushedRow(mutation.GetData())
+	dropped := masks.unmask(name, data)
+	if mutation.GetOperation() == chat.Operation_OPERATION_INSERT {
+		if mutation.GetExpectedVersion() != "" {
+			return failed("inserts take no expected version")
+		}
+		version, err := writeRow(tx, system, table, types[name], "", nil, data, false)
+		if err != nil {
+			return failed("%v", err)
+		}
+		return &chat.MutationResult{Status: chat.MutationStatus_MUTATION_STATUS_APPLIED, RowVersion: version, Message: unmaskedMessage(dropped)}
+	}
+	if mutation.GetOperation() != chat.Operation_OPERATION_UPDATE && mutation.GetOperation() != chat.Operation_OPERATION_DELETE {
+		return failed("unsupported operation %s", mutation.GetOperation())
+	}
+
+	key := pushedRow(mutation.GetKey())
+	if len(key) == 0 {
+		return failed("%s of %s has no key", mutation.GetOperation(), name)
+	}
+	for column, value := range key {
+		if value == nil {
+			return failed("key column %s is NULL", column)
+		}
+		if masks.masked(name, column) {
+			return failed("key column %s is masked", column)
+		}
+	}
+	where, args, err := keyCondition(types[name], key)
+	if err != nil {
+		return failed("%v", err)
+	}
+	current, err := lockRows(tx, system, table, where, args)
+	if err != nil {
+		return failed("%v", err)
+	}
+	switch {
+	case len(current) == 0:
+		return &chat.MutationResult{Status: chat.MutationStatus_MUTATION_STATUS_NOT_FOUND}
+	case len(current) > 1:
+		return failed("key matches %d rows of %s", len(current), name)
+	case mutation.GetExpectedVersion() != "" && mutation.GetExpectedVersion() != current[0].Version:
+		return &chat.MutationResult{
+			Status:     chat.MutationStatus_MUTATION_STATUS_VERSION_MISMATCH,
+			RowVersion: current[0].Version,
+			Message:    fmt.Sprintf("row is at version %s", current[0].Version),
+		}
+	}
+
+	if mutation.GetOperation() == chat.Operation_OPERATION_DELETE {
+		if err := deleteRows(tx, table, where, args); err != nil {
+			return failed("%v", err)
+		}
+		return &chat.MutationResult{Status: chat.MutationStatus_MUTATION_STATUS_APPLIED}
+	}
+	version, err := writeRow(tx, system, table, types[name], where, args, data, true)
+	if err != nil {
+		return failed("%v", err)
+	}
+	return &chat.MutationResult{Status: chat.MutationStatus_MUTATION_STATUS_APPLIED, RowVersion: version, Message: unmaskedMessage(dropped)}
+}
@@ -0,0 +1,55 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Row versions name the transaction that last wrote a row: the system identifier of
+// the source database and the 64-bit id of the transaction, "<system>:<xid>". Rows
+// only carry the low 32 bits of the id, which wrap around, so they are widened to
+// the 64-bit id closest to the next one. Ids stay unique for the life of the
+// database, and versions of different sources never match.
+
+// nextXidSQL is the SQL for the 64-bit id the next transaction gets
+const nextXidSQL = "(SELECT pg_snapshot_xmax(pg_current_snapshot())::text::bigint)"
+
+// xidSQL is the SQL for the 64-bit id of the transaction whose 32-bit id is in
+// column
+func xidSQL(column string) string {
+	return fmt.Sprintf("(%[1]s + ((%[2]s::text::bigint - %[1]s + 2147483648) %% 4294967296 + 4294967296) %% 4294967296 - 2147483648)",
+		nextXidSQL, column)
+}
+
+// versionSQL is the SQL for the version of the row whose xmin is in column, given
+// the system identifier as a parameter
+func versionSQL(column string) string {
+	return fmt.Sprintf("CAST(? AS text) || ':' || %s", xidSQL(column))
+}
+
+// rowVersion formats the version of a row written by transaction xid of system
+func rowVersion(system string, xid uint64) string {
+	return system + ":" + strconv.FormatUint(xid, 10)
+}
+
+// widenXid returns the 64-bit id of the transaction with the 32-bit id xid that is
+// closest to the 64-bit id ref
+func widenXid(ref uint64, xid uint32) uint64 {
+	return ref + uint64(int64(int32(xid-uint32(ref))))
+}
+
+// nextXid returns the 64-bit id the next transaction gets
+func nextXid(ctx context.Context, conn *pgx.Conn) (uint64, error) {
+	var next string
+	if err := conn.QueryRow(ctx, "SELECT "+nextXidSQL+"::text").Scan(&next); err != nil {
+		return 0, fmt.Errorf("failed to read next transaction id: %w", err)
+	}
+	xid, err := strconv.ParseUint(next, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid next transaction id %q: %w", next, err)
+	}
+	return xid, nil
+}
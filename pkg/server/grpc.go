@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/core"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/fanout"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/replication"
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/session"
+)
+
+// Service serves an engine over gRPC
+type Service struct {
+	chat.UnimplementedChatServiceServer
+	engine *core.Engine
+}
+
+func NewService(engine *core.Engine) *Service {
+	return &Service{engine: engine}
+}
+
+// Connect opens a session for a client, assigning it an id unless it brings its own
+func (s *Service) Connect(ctx context.Context, req *chat.ConnectRequest) (*chat.ConnectResponse, error) {
+	resp, err := s.engine.Connect(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return resp, nil
+}
+
+func (s *Service) StreamDataChanges(req *chat.StreamDataChangesRequest, stream chat.ChatService_StreamDataChangesServer) error {
+	return statusError(s.engine.Stream(stream.Context(), req, stream.Send))
+}
+
+// SyncDataChanges streams data changes under flow control. The first message starts
+// the stream, the following ones acknowledge what the client applied.
+func (s *Service) SyncDataChanges(stream chat.ChatService_SyncDataChangesServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	req := first.GetStart()
+	if req == nil {
+		return status.Error(codes.InvalidArgument, "the first message must start the stream")
+	}
+
+	acks := make(chan *chat.Ack)
+	go func() {
+		defer close(acks)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			// Only acks are expected once the stream started
+			ack := msg.GetAck()
+			if ack == nil {
+				continue
+			}
+			select {
+			case acks <- ack:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+	return statusError(s.engine.Sync(stream.Context(), req, acks, stream.Send))
+}
+
+// SubmitChanges applies a batch of mutations to published tables in one transaction
+func (s *Service) SubmitChanges(ctx context.Context, req *chat.SubmitChangesRequest) (*chat.SubmitChangesResponse, error) {
+	resp, err := s.engine.Submit(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return resp, nil
+}
+
+// PushChanges applies the changes a replica pushes, reporting the outcome of each
+// once the replica closes the stream. A change the source cannot apply for now ends
+// the stream with UNAVAILABLE, so the replica pushes it and the ones after it again.
+func (s *Service) PushChanges(stream chat.ChatService_PushChangesServer) error {
+	var results []*chat.ChangeResult
+	for {
+		push, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&chat.PushChangesResponse{Results: results})
+		}
+		if err != nil {
+			return err
+		}
+		result, err := s.engine.Push(stream.Context(), push)
+		if err != nil {
+			return statusError(err)
+		}
+		results = append(results, result)
+	}
+}
+
+// AdminService serves the administration of an engine over gRPC
+type AdminService struct {
+	chat.UnimplementedAdminServiceServer
+	engine *core.Engine
+}
+
+func NewAdminService(engine *core.Engine) *AdminService {
+	return &AdminService{engine: engine}
+}
+
+// ListSessions lists the sessions of the connected clients
+func (s *AdminService) ListSessions(ctx context.Context, req *chat.ListSessionsRequest) (*chat.ListSessionsResponse, error) {
+	resp, err := s.engine.ListSessions(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return resp, nil
+}
+
+// statusError maps the errors of the engine to gRPC status codes. Slow clients
+// are told to come back, so they resume from their last applied position.
+func statusError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, core.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, replication.ErrPositionUnavailable):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, fanout.ErrSlowConsumer):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, session.ErrDuplicateClient):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, session.ErrUnknownSession):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, session.ErrReplaced):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, replication.ErrPushUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,346 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jckhoe-sandbox/syncer-playground/pkg/chat"
+)
+
+// ProtocolVersion is the highest protocol version the server speaks
+const ProtocolVersion = 1
+
+// Capabilities are the features of the protocol the server supports
+var Capabilities = []string{"snapshot", "row-filters", "columns", "schema-changes", "push", "submit", "flow-control"}
+
+var (
+	// ErrDuplicateClient is returned when a client connects with the id of a client
+	// that is still streaming, without the token of its session
+	ErrDuplicateClient = errors.New("client id is already in use")
+	// ErrUnknownSession is returned for a stream whose session token is unknown or
+	// expired. The client has to connect again.
+	ErrUnknownSession = errors.New("unknown session")
+	// ErrReplaced ends the stream of a session that another stream took over
+	ErrReplaced = errors.New("session taken over by another stream")
+)
+
+// Queue is the queue of events waiting for a streaming client
+type Queue interface {
+	Depth() int
+}
+
+// Session is a client known to the server, from Connect until it has not streamed
+// for the idle timeout
+type Session struct {
+	ID              string
+	Token           string
+	Origin          string
+	Capabilities    []string
+	ProtocolVersion uint32
+	ConnectedAt     time.Time
+
+	mu sync.Mutex
+	// Counts the streams attached, so a stream that was replaced leaves the
+	// session to its successor when it ends
+	stream    uint64
+	streaming bool
+	cancel    context.CancelCauseFunc
+	filters   *chat.StreamDataChangesRequest
+	queue     Queue
+	cursor    string
+	sentAt    time.Time
+	// Position the client acknowledged as applied on a flow controlled stream, its
+	// bus cursor if known, and the window it granted
+	acked       string
+	ackedCursor string
+	window      uint32
+	idleSince   time.Time
+	// Sessions of streams that did not connect end with the stream
+	ephemeral bool
+}
+
+// Track records the queue of the stream, to report its depth
+func (s *Session) Track(queue Queue) {
+	s.mu.Lock()
+	s.queue = queue
+	s.mu.Unlock()
+}
+
+// Sent records event as the last one sent to the client
+func (s *Session) Sent(event *chat.DataChangeEvent) {
+	if event.GetPosition() == "" {
+		return
+	}
+	s.mu.Lock()
+	s.cursor = event.GetPosition()
+	s.sentAt = event.GetTimestamp().AsTime()
+	s.mu.Unlock()
+}
+
+// Acked records position, whose bus cursor is cursor, as durably applied by the
+// client, which grants window events of credit. The position never moves back.
+func (s *Session) Acked(position, cursor string, window uint32) {
+	s.mu.Lock()
+	if position > s.acked {
+		s.acked = position
+		s.ackedCursor = cursor
+	}
+	s.window = window
+	s.mu.Unlock()
+}
+
+// Info describes the session. head is the commit time of the newest event, the
+// lag is measured against it.
+func (s *Session) Info(head time.Time) *chat.Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := &chat.Session{
+		ClientId:        s.ID,
+		Origin:          s.Origin,
+		Capabilities:    s.Capabilities,
+		ProtocolVersion: s.ProtocolVersion,
+		ConnectedAt:     timestamppb.New(s.ConnectedAt),
+		Streaming:       s.streaming,
+		Cursor:          s.cursor,
+		Lag:             durationpb.New(0),
+		AckedCursor:     s.acked,
+		Window:          s.window,
+	}
+	if s.filters != nil {
+		info.Tables = s.filters.GetTables()
+		info.RowFilters = s.filters.GetRowFilters()
+		info.Columns = s.filters.GetColumns()
+	}
+	if s.streaming && s.queue != nil {
+		info.QueueDepth = uint64(s.queue.Depth())
+	}
+	if info.QueueDepth > 0 && !s.sentAt.IsZero() && head.After(s.sentAt) {
+		info.Lag = durationpb.New(head.Sub(s.sentAt))
+	}
+	return info
+}
+
+// Registry keeps the sessions of the clients of a server
+type Registry struct {
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	byID    map[string]*Session
+	byToken map[string]*Session
+}
+
+// NewRegistry creates a registry forgetting sessions that have not streamed for
+// idleTimeout
+func NewRegistry(idleTimeout time.Duration) *Registry {
+	return &Registry{
+		idleTimeout: idleTimeout,
+		byID:        make(map[string]*Session),
+		byToken:     make(map[string]*Session),
+	}
+}
+
+// Open starts a session for the client connecting with req. A client id still
+// streaming is refused with ErrDuplicateClient, unless req carries the token of
+// its session: that is the client reconnecting, and its old stream is ended.
+func (r *Registry) Open(req *chat.ConnectRequest) (*Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.open(req.GetClientId(), req.GetSessionToken())
+	if err != nil {
+		return nil, err
+	}
+	s.Origin = req.GetOrigin()
+	s.ProtocolVersion = negotiate(req.GetProtocolVersion())
+	s.Capabilities = supported(req.GetCapabilities())
+	s.cursor = req.GetCursor()
+	return s, nil
+}
+
+// Attach attaches the stream requested by req to its session, ending the stream
+// the session had. The returned context is cancelled with ErrReplaced if another
+// stream takes over, and detach must be called once the stream ends.
+func (r *Registry) Attach(ctx context.Context, req *chat.StreamDataChangesRequest) (*Session, context.Context, func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var s *Session
+	if token := req.GetSessionToken(); token != "" {
+		r.prune(time.Now())
+		if s = r.byToken[token]; s == nil {
+			return nil, nil, nil, ErrUnknownSession
+		}
+	} else {
+		var err error
+		if s, err = r.open(req.GetClientId(), ""); err != nil {
+			return nil, nil, nil, err
+		}
+		s.ProtocolVersion = ProtocolVersion
+		s.ephemeral = true
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel(ErrReplaced)
+	}
+	s.stream++
+	stream := s.stream
+	s.streaming = true
+	s.cancel = cancel
+	s.filters = req
+	s.queue = nil
+	s.acked = ""
+	s.ackedCursor = ""
+	s.window = 0
+	s.mu.Unlock()
+
+	detach := func() {
+		cancel(nil)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.stream != stream {
+			return
+		}
+		s.streaming = false
+		s.cancel = nil
+		s.queue = nil
+		s.acked = ""
+		s.ackedCursor = ""
+		s.idleSince = time.Now()
+		if s.ephemeral {
+			r.remove(s)
+		}
+	}
+	return s, ctx, detach, nil
+}
+
+// List returns the sessions, ordered by client id
+func (r *Registry) List() []*Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+	sessions := make([]*Session, 0, len(r.byID))
+	for _, s := range r.byID {
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions
+}
+
+// Slowest returns the oldest position acknowledged by a streaming client and its
+// bus cursor, empty if none acknowledged any
+func (r *Registry) Slowest() (string, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slowest, cursor := "", ""
+	for _, s := range r.byID {
+		s.mu.Lock()
+		if s.streaming && s.acked != "" && (slowest == "" || s.acked < slowest) {
+			slowest, cursor = s.acked, s.ackedCursor
+		}
+		s.mu.Unlock()
+	}
+	return slowest, cursor
+}
+
+// open registers a new session for id, assigning an id if it is empty. It must be
+// called with r.mu held.
+func (r *Registry) open(id, token string) (*Session, error) {
+	r.prune(time.Now())
+
+	if id == "" {
+		var err error
+		if id, err = randomID(8); err != nil {
+			return nil, fmt.Errorf("failed to assign client id: %w", err)
+		}
+	}
+	if old := r.byID[id]; old != nil {
+		old.mu.Lock()
+		streaming := old.streaming
+		if token != "" && token == old.Token && old.cancel != nil {
+			old.cancel(ErrReplaced)
+		}
+		old.mu.Unlock()
+		if streaming && token != old.Token {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateClient, id)
+		}
+		r.remove(old)
+	}
+
+	token, err := randomID(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue session token: %w", err)
+	}
+	s := &Session{ID: id, Token: token, ConnectedAt: time.Now()}
+	r.byID[id] = s
+	r.byToken[token] = s
+	return s, nil
+}
+
+// prune forgets the sessions that have not streamed for the idle timeout
+func (r *Registry) prune(now time.Time) {
+	for _, s := range r.byID {
+		s.mu.Lock()
+		idleSince := s.idleSince
+		if idleSince.IsZero() {
+			idleSince = s.ConnectedAt
+		}
+		expired := !s.streaming && now.Sub(idleSince) > r.idleTimeout
+		s.mu.Unlock()
+		if expired {
+			r.remove(s)
+		}
+	}
+}
+
+func (r *Registry) remove(s *Session) {
+	if r.byID[s.ID] == s {
+		delete(r.byID, s.ID)
+	}
+	delete(r.byToken, s.Token)
+}
+
+// negotiate returns the protocol version to speak with a client speaking up to version
+func negotiate(version uint32) uint32 {
+	if version == 0 || version > ProtocolVersion {
+		return ProtocolVersion
+	}
+	return version
+}
+
+// supported returns the capabilities the server supports among requested
+func supported(requested []string) []string {
+	var accepted []string
+	for _, capability := range requested {
+		for _, known := range Capabilities {
+			if capability == known {
+				accepted = append(accepted, capability)
+				break
+			}
+		}
+	}
+	return accepted
+}
+
+func randomID(size int) (string, error) {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}